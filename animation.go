@@ -0,0 +1,56 @@
+package main
+
+import (
+	"image"
+	"image/color/palette"
+	imagedraw "image/draw"
+	"image/gif"
+	"log"
+	"os"
+
+	"github.com/fogleman/gg"
+)
+
+// gifWriter 把每一帧转换为调色板图片，最后保存为 GIF 动画
+type gifWriter struct {
+	anim gif.GIF
+}
+
+// addFrame 添加一帧，delay 的单位是 1/100 秒
+func (w *gifWriter) addFrame(img image.Image, delay int) {
+	bounds := img.Bounds()
+	paletted := image.NewPaletted(bounds, palette.Plan9)
+	imagedraw.FloydSteinberg.Draw(paletted, bounds, img, bounds.Min)
+	w.anim.Image = append(w.anim.Image, paletted)
+	w.anim.Delay = append(w.anim.Delay, delay)
+}
+
+func (w *gifWriter) save(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	err = gif.EncodeAll(f, &w.anim)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// drawCountdown 模拟 grub 的倒计时，从 timeout 秒到 0 秒每秒画一帧
+func drawCountdown(root *Node, ec *EvalContext) {
+	var w gifWriter
+	for left := optTimeout; left >= 0; left-- {
+		log.Printf("countdown frame: %ds left\n", left)
+		ec.setUnknown("timeout-left", float64(left))
+		ctx := gg.NewContext(optScreenWidth, optScreenHeight)
+		root.DrawTo(ctx, ec)
+		w.addFrame(ctx.Image(), 100)
+	}
+
+	err := w.save(optOutput)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
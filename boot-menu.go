@@ -2,6 +2,7 @@ package main
 
 import (
 	"image/color"
+	"log"
 	"strings"
 
 	"github.com/electricface/grub-theme-viewer/font"
@@ -11,28 +12,6 @@ import (
 	"github.com/fogleman/gg"
 )
 
-type menuItem struct {
-	icon string
-	text string
-}
-
-var menuItems = []*menuItem{
-	{
-		icon: "deepin",
-		text: "Deepin GNU/Linux",
-	},
-	{
-		text: "Advanced options for Deepin GNU/Linux",
-	},
-	{
-		icon: "windows",
-		text: "Window XP",
-	},
-	{
-		text: "System setup",
-	},
-}
-
 type BootMenu struct {
 	CompCommon
 
@@ -64,8 +43,27 @@ type BootMenu struct {
 	scrollbarWidth tt.Length
 	scrollbarFrame string
 	scrollbarThumb string
+
+	scrollbarThumbOverlay bool
+	scrollbarSlice        string
+	scrollbarLeftPad      tt.Length
+	scrollbarRightPad     tt.Length
+	scrollbarTopPad       tt.Length
+	scrollbarBottomPad    tt.Length
+
+	entries []*menuEntry
+	// 选中的菜单项
+	selected int
+	// 第一个显示的菜单项
+	firstShown int
 }
 
+const (
+	scrollbarSliceWest   = "west"
+	scrollbarSliceCenter = "center"
+	scrollbarSliceEast   = "east"
+)
+
 func (bm *BootMenu) getItemHeight() Expr {
 	return AbsNum(bm.itemHeight.(tt.AbsNum))
 }
@@ -90,6 +88,26 @@ func (bm *BootMenu) getItemIconSpace() Expr {
 	return AbsNum(bm.itemIconSpace.(tt.AbsNum))
 }
 
+func (bm *BootMenu) getScrollbarWidth() Expr {
+	return AbsNum(bm.scrollbarWidth.(tt.AbsNum))
+}
+
+func (bm *BootMenu) getScrollbarLeftPad() Expr {
+	return AbsNum(bm.scrollbarLeftPad.(tt.AbsNum))
+}
+
+func (bm *BootMenu) getScrollbarRightPad() Expr {
+	return AbsNum(bm.scrollbarRightPad.(tt.AbsNum))
+}
+
+func (bm *BootMenu) getScrollbarTopPad() Expr {
+	return AbsNum(bm.scrollbarTopPad.(tt.AbsNum))
+}
+
+func (bm *BootMenu) getScrollbarBottomPad() Expr {
+	return AbsNum(bm.scrollbarBottomPad.(tt.AbsNum))
+}
+
 func (bm *BootMenu) getItemColor() color.Color {
 	return parseColor(bm.itemColor)
 }
@@ -99,34 +117,27 @@ func (bm *BootMenu) getSelectedItemColor() color.Color {
 }
 
 func (cc *CompCommon) fillCommonOptions(comp *tt.Component) {
-	var ok bool
 	cc.id, _ = comp.GetPropString("id")
 
-	cc.left, ok = comp.GetPropLength("left")
-	if !ok {
-		cc.left = tt.AbsNum(0)
-	}
+	cc.left, _ = comp.GetPropLength("left")
 	cc.node.left = cc.left
 
-	cc.top, ok = comp.GetPropLength("top")
-	if !ok {
-		cc.top = tt.AbsNum(0)
-	}
+	cc.top, _ = comp.GetPropLength("top")
 	cc.node.top = cc.top
 
-	cc.width, ok = comp.GetPropLength("width")
-	if !ok {
-		cc.width = tt.AbsNum(0)
-	}
+	cc.width, _ = comp.GetPropLength("width")
 	cc.node.width = cc.width
 
-	cc.height, ok = comp.GetPropLength("height")
-	if !ok {
-		cc.height = tt.AbsNum(0)
-	}
+	cc.height, _ = comp.GetPropLength("height")
 	cc.node.height = cc.height
 }
 
+// getPropAbsNum 读取 grub 中用 grub_strtol 解析的属性，比如 item_height
+func getPropAbsNum(comp *tt.Component, name string) tt.Length {
+	v, _ := comp.GetPropNumber(name)
+	return tt.AbsNum(v)
+}
+
 func newBootMenu(comp *tt.Component, parent *Node) *BootMenu {
 	bm := &BootMenu{}
 	bm.node = &Node{
@@ -135,190 +146,374 @@ func newBootMenu(comp *tt.Component, parent *Node) *BootMenu {
 
 	bm.fillCommonOptions(comp)
 	var ok bool
-	bm.visible, ok = comp.GetPropBool("visible")
-	if !ok {
-		bm.visible = true
-	}
+	bm.visible, _ = comp.GetPropBool("visible")
 
 	bm.menuPixmapStyle, _ = comp.GetPropString("menu_pixmap_style")
 
 	bm.padLeft, bm.padRight, bm.padTop, bm.padBottom = getPads(bm.menuPixmapStyle)
 
-	bm.itemFont, ok = comp.GetPropString("item_font")
-	if !ok {
-		bm.itemFont = "Unknown Regular 16"
-	}
+	bm.itemFont, _ = comp.GetPropString("item_font")
 
-	bm.itemColor, ok = comp.GetPropString("item_color")
-	if !ok {
-		bm.itemColor = "black"
-	}
+	bm.itemColor, _ = comp.GetPropString("item_color")
 
 	bm.itemPixmapStyle, _ = comp.GetPropString("item_pixmap_style")
 
-	bm.selectedItemFont, ok = comp.GetPropString("selected_item_font")
-	if !ok {
+	// 默认值 inherit 表示和未选中的菜单项相同
+	bm.selectedItemFont, _ = comp.GetPropString("selected_item_font")
+	if bm.selectedItemFont == "inherit" {
 		bm.selectedItemFont = bm.itemFont
 	}
 
-	bm.selectedItemColor, ok = comp.GetPropString("selected_item_color")
-	if !ok {
+	bm.selectedItemColor, _ = comp.GetPropString("selected_item_color")
+	if bm.selectedItemColor == "inherit" {
 		bm.selectedItemColor = bm.itemColor
 	}
 
 	bm.selectedItemPixmapStyle, _ = comp.GetPropString("selected_item_pixmap_style")
 
-	bm.itemHeight, ok = comp.GetPropLength("item_height")
-	if !ok {
-		// set default value
-		bm.itemHeight = tt.AbsNum(42)
-	}
+	bm.itemHeight = getPropAbsNum(comp, "item_height")
 
-	bm.itemPadding, ok = comp.GetPropLength("item_padding")
-	if !ok {
-		bm.itemPadding = tt.AbsNum(14)
-	}
+	bm.itemPadding = getPropAbsNum(comp, "item_padding")
 
-	bm.itemSpacing, ok = comp.GetPropLength("item_spacing")
-	if !ok {
-		bm.itemSpacing = tt.AbsNum(16)
-	}
+	bm.itemSpacing = getPropAbsNum(comp, "item_spacing")
 
-	bm.iconWidth, ok = comp.GetPropLength("icon_width")
-	if !ok {
-		bm.iconWidth = tt.AbsNum(32)
-	}
+	bm.iconWidth = getPropAbsNum(comp, "icon_width")
 
-	bm.iconHeight, ok = comp.GetPropLength("icon_height")
-	if !ok {
-		bm.iconHeight = tt.AbsNum(32)
-	}
+	bm.iconHeight = getPropAbsNum(comp, "icon_height")
 
-	bm.itemIconSpace, ok = comp.GetPropLength("item_icon_space")
-	if !ok {
-		bm.itemIconSpace = tt.AbsNum(4)
-	}
+	bm.itemIconSpace = getPropAbsNum(comp, "item_icon_space")
 
-	bm.scrollbar, ok = comp.GetPropBool("scrollbar")
-	if !ok {
-		bm.scrollbar = true
-	}
+	bm.scrollbar, _ = comp.GetPropBool("scrollbar")
 
-	bm.scrollbarWidth, ok = comp.GetPropLength("scrollbar_width")
-	if !ok {
-		bm.scrollbarWidth = tt.AbsNum(16)
-	}
+	bm.scrollbarWidth = getPropAbsNum(comp, "scrollbar_width")
 
 	bm.scrollbarFrame, _ = comp.GetPropString("scrollbar_frame")
 	bm.scrollbarThumb, _ = comp.GetPropString("scrollbar_thumb")
+	bm.scrollbarThumbOverlay, _ = comp.GetPropBool("scrollbar_thumb_overlay")
+
+	bm.scrollbarSlice, ok = comp.GetPropString("scrollbar_slice")
+	switch bm.scrollbarSlice {
+	case scrollbarSliceWest, scrollbarSliceCenter, scrollbarSliceEast:
+	default:
+		if ok {
+			log.Printf("WARN: invalid scrollbar_slice %q\n", bm.scrollbarSlice)
+		}
+		bm.scrollbarSlice = scrollbarSliceEast
+	}
+
+	bm.scrollbarLeftPad = getPropAbsNum(comp, "scrollbar_left_pad")
+
+	bm.scrollbarRightPad = getPropAbsNum(comp, "scrollbar_right_pad")
+
+	bm.scrollbarTopPad = getPropAbsNum(comp, "scrollbar_top_pad")
+
+	bm.scrollbarBottomPad = getPropAbsNum(comp, "scrollbar_bottom_pad")
 
 	return bm
 }
 
-func compBootMenuToNode(comp *tt.Component, parent *Node) *Node {
-	bm := newBootMenu(comp, parent)
-	bmNode := bm.node
+// setPrefSize 参考 grub 的 list_get_minimal_size，大小按能显示 3 个菜单项计算
+func (bm *BootMenu) setPrefSize() {
+	const numItems = 3
+	textWidth := maxInt(getFont(bm.itemFont).MeasureString("Typical OS"),
+		getFont(bm.selectedItemFont).MeasureString("Typical OS"))
+
+	// width = textWidth + 2 * itemPadding + padLeft + padRight
+	// + iconWidth + itemIconSpace
+	bm.node.prefWidth = add(add(add(AbsNum(textWidth),
+		mul(AbsNum(2), bm.getItemPadding())),
+		AbsNum(bm.padLeft+bm.padRight)),
+		add(bm.getIconWidth(), bm.getItemIconSpace()))
+
+	// height = itemHeight * numItems + itemSpacing * (numItems - 1)
+	// + 2 * itemPadding + padTop + padBottom
+	bm.node.prefHeight = add(add(add(mul(bm.getItemHeight(), AbsNum(numItems)),
+		mul(bm.getItemSpacing(), AbsNum(numItems-1))),
+		mul(AbsNum(2), bm.getItemPadding())),
+		AbsNum(bm.padTop+bm.padBottom))
+}
 
-	y := add(AbsNum(bm.padBottom), bm.getItemPadding())
+// getNumShownItems 参考 grub 的 get_num_shown_items，返回能显示的菜单项数量
+func (bm *BootMenu) getNumShownItems(ec *EvalContext) int {
+	itemHeight := int(bm.getItemHeight().Eval(ec))
+	itemSpacing := int(bm.getItemSpacing().Eval(ec))
+	itemPadding := int(bm.getItemPadding().Eval(ec))
+	if itemHeight+itemSpacing <= 0 {
+		return 1
+	}
 
+	_, _, itemPadTop, itemPadBottom := getPads(bm.itemPixmapStyle)
+	_, _, selPadTop, selPadBottom := getPads(bm.selectedItemPixmapStyle)
+	maxPadTop := maxInt(itemPadTop, selPadTop)
+	maxPadBottom := maxInt(itemPadBottom, selPadBottom)
+
+	height := int(bm.node.getHeight().Eval(ec))
+	return (height + itemSpacing - 2*itemPadding - maxPadTop - maxPadBottom -
+		bm.padTop - bm.padBottom) / (itemHeight + itemSpacing)
+}
+
+// isDrawingScrollbar 菜单项显示不完时才画滚动条，和 grub 的 check_scrollbar 一样，
+// 滚动条要比 thumb 的左右边距宽
+func (bm *BootMenu) isDrawingScrollbar(ec *EvalContext) bool {
+	thumbPadLeft, thumbPadRight, _, _ := getPads(bm.scrollbarThumb)
+	return bm.scrollbar && bm.getNumShownItems(ec) < len(bm.entries) &&
+		float64(thumbPadLeft+thumbPadRight) < bm.getScrollbarWidth().Eval(ec)
+}
+
+// scrollbarSpace 是滚动条在菜单内容区域中占用的宽度，
+// 只有 scrollbar_slice 为 center 时滚动条才在内容区域中，参考 grub 的 list_paint 占用
+// scrollbar_width + 2。
+type scrollbarSpace struct {
+	bm *BootMenu
+}
+
+func (e scrollbarSpace) Eval(ec *EvalContext) float64 {
+	bm := e.bm
+	if bm.scrollbarSlice != scrollbarSliceCenter || !bm.isDrawingScrollbar(ec) {
+		return 0
+	}
+	return add(bm.getScrollbarWidth(), AbsNum(2)).Eval(ec)
+}
+
+func (e scrollbarSpace) ExprString() string {
+	return "scrollbarSpace"
+}
+
+// drawScrollbar 参考 grub 的 list_paint，根据 scrollbar_slice 确定滚动条的位置，
+// 然后画出滚动条
+func (bm *BootMenu) drawScrollbar(ctx *gg.Context, ec *EvalContext) {
+	n := bm.node
+	x := int(n.getLeft().Eval(ec))
+	y := int(n.getTop().Eval(ec))
+	width := int(n.getWidth().Eval(ec))
+	height := int(n.getHeight().Eval(ec))
+
+	sbWidth := int(bm.getScrollbarWidth().Eval(ec))
+	sbLeftPad := int(bm.getScrollbarLeftPad().Eval(ec))
+	sbRightPad := int(bm.getScrollbarRightPad().Eval(ec))
+	sbTopPad := int(bm.getScrollbarTopPad().Eval(ec))
+	sbBottomPad := int(bm.getScrollbarBottomPad().Eval(ec))
+
+	var sbX int
+	switch bm.scrollbarSlice {
+	case scrollbarSliceWest:
+		// 在西边的切片中右对齐，切片不够宽时占满切片
+		if bm.padLeft < sbWidth {
+			sbX = 0
+			sbWidth = bm.padLeft
+		} else {
+			sbX = bm.padLeft - sbWidth
+		}
+	case scrollbarSliceCenter:
+		// 在内容区域的右边
+		sbX = width - bm.padRight - sbWidth
+	default:
+		// 占满东边的切片
+		sbX = width - bm.padRight
+		sbWidth = bm.padRight
+	}
+	sbX += x + sbLeftPad
+	sbWidth -= sbLeftPad + sbRightPad
+	sbY := y + bm.padTop + sbTopPad
+	sbHeight := height - bm.padTop - bm.padBottom - sbTopPad - sbBottomPad
+
+	numShown := bm.getNumShownItems(ec)
+	bm.drawScrollbarRect(ctx, sbX, sbY, sbWidth, sbHeight,
+		bm.firstShown, numShown, 0, len(bm.entries))
+}
+
+// drawScrollbarRect 参考 grub 的 draw_scrollbar，value 是第一个显示的菜单项，
+// extent 是显示的菜单项数量，[min, max) 是全部菜单项的范围。
+func (bm *BootMenu) drawScrollbarRect(ctx *gg.Context, x, y, width, height int,
+	value, extent, min, max int) {
+	framePadLeft, framePadRight, framePadTop, framePadBottom := getPads(bm.scrollbarFrame)
+	thumbPadLeft, thumbPadRight, thumbPadTop, thumbPadBottom := getPads(bm.scrollbarThumb)
+	frameVPad := framePadTop + framePadBottom
+	frameHPad := framePadLeft + framePadRight
+	thumbVPad := thumbPadTop + thumbPadBottom
+	thumbHPad := thumbPadLeft + thumbPadRight
+
+	trackTop := framePadTop
+	trackLen := 0
+	if height > frameVPad {
+		trackLen = height - frameVPad
+	}
+	if bm.scrollbarThumbOverlay {
+		trackLen += thumbVPad
+		trackTop -= thumbPadTop
+	}
+
+	var thumbY, thumbHeight int
+	if value > min && max > min {
+		thumbY = trackLen * (value - min) / (max - min)
+	}
+	if max <= min {
+		thumbHeight = 1
+	} else {
+		thumbHeight = trackLen*extent/(max-min) + 1
+	}
+	// 菜单项太多或者滚动条太短
+	if thumbHeight < thumbVPad {
+		thumbHeight = thumbVPad
+		if value <= min || max <= extent || trackLen <= thumbVPad {
+			thumbY = 0
+		} else {
+			thumbY = (trackLen - thumbVPad) * (value - min) / (max - extent)
+		}
+	}
+	thumbY += trackTop
+
+	thumbX := framePadLeft
+	thumbWidth := width - frameHPad
+	if bm.scrollbarThumbOverlay {
+		thumbX -= thumbPadLeft
+	} else {
+		thumbWidth -= thumbHPad
+	}
+
+	drawStyleBoxRect(ctx, bm.scrollbarFrame, x, y, width, height)
+	// thumbWidth 和 thumbHeight 是中间切片的大小
+	drawStyleBoxRect(ctx, bm.scrollbarThumb, x+thumbX, y+thumbY,
+		thumbWidth+thumbHPad, thumbHeight)
+}
+
+// newItemNode 返回菜单项的节点，y 是菜单项相对菜单的位置
+func (bm *BootMenu) newItemNode(entry *menuEntry, selected bool, y Expr) *Node {
 	// itemWidth = bootMenu.width - (2 * itemPadding) - 2
-	// - bootMenu.padLeft - bootMenu.padRight
-	itemWidthExpr := sub(sub(sub(sub(bmNode.getWidth(),
+	// - bootMenu.padLeft - bootMenu.padRight - scrollbarSpace
+	itemWidthExpr := sub(sub(sub(sub(sub(nodeWidth{bm.node},
 		mul(AbsNum(2), bm.getItemPadding())), AbsNum(2)),
-		AbsNum(bm.padLeft)), AbsNum(bm.padRight))
+		AbsNum(bm.padLeft)), AbsNum(bm.padRight)), scrollbarSpace{bm})
 
 	// itemLeft = bootMenu.padLeft + bootMenu.ItemPadding
 	itemLeftExpr := add(AbsNum(bm.padLeft), bm.getItemPadding())
 
-	for i := 0; i < 4; i++ {
-		// add item
-		item := &Node{
-			leftExpr:  itemLeftExpr,
-			topExpr:   y,
-			widthExpr: itemWidthExpr,
-			height:    bm.itemHeight,
-		}
+	item := &Node{
+		leftExpr:  itemLeftExpr,
+		topExpr:   y,
+		widthExpr: itemWidthExpr,
+		height:    bm.itemHeight,
+	}
 
-		// select first item
-		var itemPixmapStyle string
-		if i == 0 {
-			item.draw = func(n *Node, ctx *gg.Context, ec *EvalContext) {
-				n.drawStyleBox(ctx, ec, bm.selectedItemPixmapStyle)
-			}
-			itemPixmapStyle = bm.selectedItemPixmapStyle
-		} else {
-			item.draw = func(n *Node, ctx *gg.Context, ec *EvalContext) {
-				n.drawStyleBox(ctx, ec, bm.itemPixmapStyle)
-			}
-			itemPixmapStyle = bm.itemPixmapStyle
-		}
+	var itemPixmapStyle string
+	if selected {
+		itemPixmapStyle = bm.selectedItemPixmapStyle
+	} else {
+		itemPixmapStyle = bm.itemPixmapStyle
+	}
+	item.draw = func(n *Node, ctx *gg.Context, ec *EvalContext) {
+		n.drawStyleBox(ctx, ec, itemPixmapStyle)
+	}
 
-		itemPadLeft, _, _, _ := getPads(itemPixmapStyle)
+	itemPadLeft, _, _, _ := getPads(itemPixmapStyle)
 
-		// iconTop = (itemHeight-iconHeight) / 2
-		iconTopExpr := div(sub(bm.getItemHeight(), bm.getIconHeight()), AbsNum(2))
+	// iconTop = (itemHeight-iconHeight) / 2
+	iconTopExpr := div(sub(bm.getItemHeight(), bm.getIconHeight()), AbsNum(2))
 
-		icon := &Node{
-			left:    tt.AbsNum(itemPadLeft),
-			topExpr: iconTopExpr,
+	icon := &Node{
+		left:    tt.AbsNum(itemPadLeft),
+		topExpr: iconTopExpr,
 
-			width:  bm.iconWidth,
-			height: bm.iconHeight,
-		}
-		idx := i
-		icon.draw = func(n *Node, ctx *gg.Context, ec *EvalContext) {
-			iconName := menuItems[idx].icon
-			n.drawImage(ctx, ec, "icons/"+iconName+".png")
+		width:  bm.iconWidth,
+		height: bm.iconHeight,
+	}
+	icon.draw = func(n *Node, ctx *gg.Context, ec *EvalContext) {
+		iconFile := globalIconManager.getIcon(entry)
+		if iconFile != "" {
+			n.drawImage(ctx, ec, iconFile)
 		}
+	}
 
-		var textColor color.Color
-		//var textFontSize int
-		var textFontFace *font.Face
-		if i == 0 {
-			textColor = bm.getSelectedItemColor()
-			textFontFace = getFont(bm.selectedItemFont)
+	var textColor color.Color
+	var textFontFace *font.Face
+	if selected {
+		textColor = bm.getSelectedItemColor()
+		textFontFace = getFont(bm.selectedItemFont)
+	} else {
+		textColor = bm.getItemColor()
+		textFontFace = getFont(bm.itemFont)
+	}
+	textFontHeight := textFontFace.Metrics().Height.Round()
+
+	// textTop = (bm.ItemHeight - textFontHeight) / 2
+	textTopExpr := div(sub(bm.getItemHeight(), AbsNum(textFontHeight)), AbsNum(2))
+
+	// textWidth = itemWidth - iconWidth - itemIconSpace
+	textWidthExpr := sub(sub(itemWidthExpr, bm.getIconWidth()),
+		bm.getItemIconSpace())
+
+	// textLeft = itemPadLeft + iconWidth + itemIconSpace
+	textLeftExpr := add(AbsNum(itemPadLeft), add(bm.getIconWidth(),
+		bm.getItemIconSpace()))
+	text := &Node{
+		leftExpr:  textLeftExpr,
+		topExpr:   textTopExpr,
+		widthExpr: textWidthExpr,
+		height:    tt.AbsNum(textFontHeight),
+	}
 
-		} else {
-			textColor = bm.getItemColor()
-			textFontFace = getFont(bm.itemFont)
-		}
-		textFontHeight := textFontFace.Metrics().Height.Round()
-
-		// textTop = (bm.ItemHeight - textFontHeight) / 2
-		textTopExpr := div(sub(bm.getItemHeight(), AbsNum(textFontHeight)), AbsNum(2))
-
-		// textWidth = itemWidth - iconWidth - itemIconSpace
-		textWidthExpr := sub(sub(itemWidthExpr, bm.getIconWidth()),
-			bm.getItemIconSpace())
-
-		// textLeft = itemPadLeft + iconWidth + itemIconSpace
-		textLeftExpr := add(AbsNum(itemPadLeft), add(bm.getIconWidth(),
-			bm.getItemIconSpace()))
-		text := &Node{
-			leftExpr:  textLeftExpr,
-			topExpr:   textTopExpr,
-			widthExpr: textWidthExpr,
-			height:    tt.AbsNum(textFontHeight),
-		}
+	text.draw = func(n *Node, ctx *gg.Context, ec *EvalContext) {
+		n.drawText(ctx, ec, entry.title, textColor, textFontFace)
+	}
 
-		text.draw = func(n *Node, ctx *gg.Context, ec *EvalContext) {
-			textStr := menuItems[idx].text
-			n.drawText(ctx, ec, textStr, textColor, textFontFace)
-		}
+	item.addChild(icon)
+	item.addChild(text)
+	return item
+}
 
-		item.addChild(icon)
-		item.addChild(text)
+// makeSelectedItemVisible 参考 grub 的 make_selected_item_visible，
+// 选中的菜单项不在显示范围内时滚动菜单。
+func (bm *BootMenu) makeSelectedItemVisible(ec *EvalContext) {
+	if bm.selected < 0 {
+		return
+	}
+	numShown := bm.getNumShownItems(ec)
+	lastShown := bm.firstShown + numShown - 1
+	if bm.selected < bm.firstShown {
+		bm.firstShown = bm.selected
+	} else if bm.selected > lastShown {
+		bm.firstShown = bm.selected - (numShown - 1)
+	}
+}
+
+// layoutItems 参考 grub 的 list_paint，只为显示出来的菜单项创建节点
+func (bm *BootMenu) layoutItems(ec *EvalContext) {
+	bm.makeSelectedItemVisible(ec)
+	numShown := bm.getNumShownItems(ec)
+
+	bm.node.Children = nil
+	y := add(AbsNum(bm.padTop), bm.getItemPadding())
+	for i := bm.firstShown; i < len(bm.entries) && i < bm.firstShown+numShown; i++ {
+		bm.node.addChild(bm.newItemNode(bm.entries[i], i == bm.selected, y))
 
 		// y += itemHeight + itemSpacing
 		y = add(y, add(bm.getItemHeight(), bm.getItemSpacing()))
+	}
+}
+
+// 节点树中所有的 boot_menu，按键时一起改变选中的菜单项
+var globalBootMenus []*BootMenu
 
-		bmNode.addChild(item)
+func compBootMenuToNode(comp *tt.Component, parent *Node) *Node {
+	bm := newBootMenu(comp, parent)
+	bmNode := bm.node
+	bm.entries = getCurrentMenuEntries()
 
+	bm.selected = globalSelected
+	if len(bm.entries) > 0 && (bm.selected < 0 || bm.selected >= len(bm.entries)) {
+		log.Printf("WARN: selected entry %d out of range\n", bm.selected)
+		bm.selected = 0
 	}
 
+	// 显示哪些菜单项取决于菜单的高度，菜单项的节点由 themeToNodeTree 在节点树建好之后创建
+	globalBootMenus = append(globalBootMenus, bm)
 	bmNode.draw = func(n *Node, ctx *gg.Context, ec *EvalContext) {
 		n.drawStyleBox(ctx, ec, bm.menuPixmapStyle)
+		if bm.isDrawingScrollbar(ec) {
+			bm.drawScrollbar(ctx, ec)
+		}
 	}
+	bm.setPrefSize()
 
 	return bmNode
 }
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	tt "github.com/electricface/grub-theme-viewer/themetxt"
+
+	"github.com/fogleman/gg"
+)
+
+// grub 中一圈的角度单位数，即 GRUB_TRIG_ANGLE_MAX
+const trigAngleMax = 256
+
+type CircularProgress struct {
+	CompCommon
+
+	visible        bool
+	centerBitmap   string
+	tickBitmap     string
+	numTicks       int
+	ticksDisappear bool
+	startAngle     int
+}
+
+// parseAngle 参考 grub 的 parse_angle，值的单位是 grub 的角度单位，
+// 带 deg 或 ° 后缀时单位是度。
+func parseAngle(str string) int {
+	str = strings.TrimSpace(str)
+	unit := strings.TrimLeft(str, "+-0123456789")
+	numStr := strings.TrimSuffix(str, unit)
+	angle, err := strconv.Atoi(numStr)
+	if err != nil {
+		log.Printf("WARN: invalid angle %q\n", str)
+		return 0
+	}
+
+	unit = strings.TrimSpace(unit)
+	if unit == "deg" || unit == "°" {
+		// grub_divide_round (angle * 64, 90)
+		angle = int(math.Round(float64(angle*64) / 90))
+	}
+	return angle
+}
+
+func newCircularProgress(comp *tt.Component) *CircularProgress {
+	cp := &CircularProgress{}
+	cp.node = &Node{}
+	cp.fillCommonOptions(comp)
+
+	cp.visible, _ = comp.GetPropBool("visible")
+
+	cp.centerBitmap, _ = comp.GetPropString("center_bitmap")
+	cp.tickBitmap, _ = comp.GetPropString("tick_bitmap")
+
+	cp.numTicks, _ = comp.GetPropNumber("num_ticks")
+
+	cp.ticksDisappear, _ = comp.GetPropBool("ticks_disappear")
+
+	startAngle, _ := comp.GetPropString("start_angle")
+	cp.startAngle = parseAngle(startAngle)
+	return cp
+}
+
+// draw 参考 grub 的 circprog_paint
+func (cp *CircularProgress) draw(n *Node, ctx *gg.Context, ec *EvalContext) {
+	if !cp.visible || cp.isHiddenTimeout(ec) {
+		return
+	}
+
+	centerImg, err := gg.LoadImage(getResourceFile(cp.centerBitmap))
+	if err != nil {
+		log.Println("WARN: load center_bitmap:", err)
+		return
+	}
+	tickImg, err := gg.LoadImage(getResourceFile(cp.tickBitmap))
+	if err != nil {
+		log.Println("WARN: load tick_bitmap:", err)
+		return
+	}
+
+	x := int(n.getLeft().Eval(ec))
+	y := int(n.getTop().Eval(ec))
+	width := int(n.getWidth().Eval(ec))
+	height := int(n.getHeight().Eval(ec))
+
+	centerWidth := centerImg.Bounds().Dx()
+	centerHeight := centerImg.Bounds().Dy()
+	ctx.DrawImage(centerImg, x+(width-centerWidth)/2, y+(height-centerHeight)/2)
+
+	if cp.numTicks <= 0 {
+		return
+	}
+
+	tickWidth := tickImg.Bounds().Dx()
+	tickHeight := tickImg.Bounds().Dy()
+	radius := minInt(width, height)/2 - maxInt(tickWidth, tickHeight)/2 - 1
+
+	nTicks := int(float64(cp.numTicks) * getProgress(ec))
+	tickBegin, tickEnd := 0, nTicks
+	// 刻度随着进度消失还是出现
+	if cp.ticksDisappear {
+		tickBegin, tickEnd = nTicks, cp.numTicks
+	}
+
+	for i := tickBegin; i < tickEnd; i++ {
+		angle := cp.startAngle + i*trigAngleMax/cp.numTicks
+		rad := float64(angle) * 2 * math.Pi / trigAngleMax
+		tickX := width/2 + int(math.Cos(rad)*float64(radius))
+		tickY := height/2 + int(math.Sin(rad)*float64(radius))
+
+		// 让刻度居中
+		tickX -= tickWidth / 2
+		tickY -= tickHeight / 2
+		ctx.DrawImage(tickImg, x+tickX, y+tickY)
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func compCircularProgressToNode(comp *tt.Component, parent *Node) *Node {
+	cp := newCircularProgress(comp)
+	cp.node.draw = cp.draw
+
+	// 参考 grub 的 circprog_get_minimal_size
+	centerImg, err := gg.LoadImage(getResourceFile(cp.centerBitmap))
+	if err == nil {
+		cp.node.prefWidth = AbsNum(centerImg.Bounds().Dx())
+		cp.node.prefHeight = AbsNum(centerImg.Bounds().Dy())
+	}
+	return cp.node
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	tt "github.com/electricface/grub-theme-viewer/themetxt"
+)
+
+// Box 对应 grub 的 hbox 和 vbox，子组件按首选大小水平或垂直排列。
+type Box struct {
+	CompCommon
+	horizontal bool
+}
+
+func newBox(comp *tt.Component, parent *Node) *Box {
+	box := &Box{}
+	box.node = &Node{
+		parent: parent,
+	}
+	box.fillCommonOptions(comp)
+	box.horizontal = comp.Type == tt.ComponentTypeHBox
+	return box
+}
+
+// getAbsLengthExpr 返回长度中不依赖父节点大小的部分，用于计算容器的首选大小，
+// 避免容器的大小和子组件的大小相互依赖。
+func getAbsLengthExpr(l tt.Length) Expr {
+	abs, rel := tt.GetLengthParts(l)
+	if rel != 0 && abs < 0 {
+		return AbsNum(0)
+	}
+	return AbsNum(abs)
+}
+
+func getPrefExpr(pref Expr) Expr {
+	if pref == nil {
+		return AbsNum(0)
+	}
+	return pref
+}
+
+// layout 参考 grub 的 layout_horizontally 和 layout_vertically，
+// 子组件在排列方向上依次排开，在另一个方向上拉伸到最大的子组件的大小。
+func (box *Box) layout() {
+	boxNode := box.node
+	var pos Expr = AbsNum(0)
+	var crossSize Expr = AbsNum(0)
+
+	// 首选大小
+	var prefMain Expr = AbsNum(0)
+	var prefCross Expr = AbsNum(0)
+
+	for _, child := range boxNode.Children {
+		// size = max(prefSize, length)
+		width := maxOf(getPrefExpr(child.prefWidth),
+			getLengthExpr(child.width, nodeWidth{boxNode}))
+		height := maxOf(getPrefExpr(child.prefHeight),
+			getLengthExpr(child.height, nodeHeight{boxNode}))
+		prefWidth := maxOf(getPrefExpr(child.prefWidth), getAbsLengthExpr(child.width))
+		prefHeight := maxOf(getPrefExpr(child.prefHeight), getAbsLengthExpr(child.height))
+
+		if box.horizontal {
+			child.leftExpr = pos
+			child.topExpr = AbsNum(0)
+			child.widthExpr = width
+			pos = add(pos, width)
+			crossSize = maxOf(crossSize, height)
+
+			prefMain = add(prefMain, prefWidth)
+			prefCross = maxOf(prefCross, prefHeight)
+		} else {
+			child.leftExpr = AbsNum(0)
+			child.topExpr = pos
+			child.heightExpr = height
+			pos = add(pos, height)
+			crossSize = maxOf(crossSize, width)
+
+			prefMain = add(prefMain, prefHeight)
+			prefCross = maxOf(prefCross, prefWidth)
+		}
+	}
+
+	for _, child := range boxNode.Children {
+		if box.horizontal {
+			child.heightExpr = crossSize
+		} else {
+			child.widthExpr = crossSize
+		}
+	}
+
+	if box.horizontal {
+		boxNode.prefWidth = prefMain
+		boxNode.prefHeight = prefCross
+	} else {
+		boxNode.prefWidth = prefCross
+		boxNode.prefHeight = prefMain
+	}
+}
+
+func compBoxToNode(comp *tt.Component, parent *Node) *Node {
+	box := newBox(comp, parent)
+	for _, childComp := range comp.Children {
+		child := compToNode(childComp, box.node)
+		if child != nil {
+			box.node.addChild(child)
+		}
+	}
+	box.layout()
+	return box.node
+}
+
+// Canvas 对应 grub 的 canvas，子组件相对 canvas 绝对定位，
+// 子组件的百分比长度相对 canvas 的大小计算。
+type Canvas struct {
+	CompCommon
+}
+
+func newCanvas(comp *tt.Component, parent *Node) *Canvas {
+	canvas := &Canvas{}
+	canvas.node = &Node{
+		parent: parent,
+	}
+	canvas.fillCommonOptions(comp)
+	return canvas
+}
+
+func compCanvasToNode(comp *tt.Component, parent *Node) *Node {
+	canvas := newCanvas(comp, parent)
+	for _, childComp := range comp.Children {
+		child := compToNode(childComp, canvas.node)
+		if child != nil {
+			canvas.node.addChild(child)
+		}
+	}
+	return canvas.node
+}
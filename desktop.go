@@ -0,0 +1,156 @@
+package main
+
+import (
+	"image"
+	"log"
+
+	tt "github.com/electricface/grub-theme-viewer/themetxt"
+
+	"github.com/fogleman/gg"
+	"github.com/nfnt/resize"
+)
+
+const (
+	scaleMethodStretch   = "stretch"
+	scaleMethodCrop      = "crop"
+	scaleMethodPadding   = "padding"
+	scaleMethodFitWidth  = "fitwidth"
+	scaleMethodFitHeight = "fitheight"
+)
+
+// Desktop 桌面背景，对应全局属性 desktop-image, desktop-color,
+// desktop-image-scale-method, desktop-image-h-align, desktop-image-v-align
+type Desktop struct {
+	image       string
+	color       string
+	scaleMethod string
+	hAlign      string
+	vAlign      string
+}
+
+func newDesktop(theme *tt.Theme) *Desktop {
+	d := &Desktop{}
+	d.image, _ = theme.GetPropString("desktop-image")
+
+	d.color, _ = theme.GetPropString("desktop-color")
+
+	d.scaleMethod, _ = theme.GetPropString("desktop-image-scale-method")
+
+	d.hAlign, _ = theme.GetPropString("desktop-image-h-align")
+
+	d.vAlign, _ = theme.GetPropString("desktop-image-v-align")
+	return d
+}
+
+// getAlignOffset 根据对齐方式计算 part 在 total 中的偏移
+func getAlignOffset(align string, total, part int) int {
+	switch align {
+	case "left", "top":
+		return 0
+	case "right", "bottom":
+		return total - part
+	default:
+		// center
+		return (total - part) / 2
+	}
+}
+
+type subImager interface {
+	SubImage(r image.Rectangle) image.Image
+}
+
+// scaleProportional 参考 grub 的 grub_video_bitmap_scale_proportional，
+// 返回缩放后的图片和它在目标区域中的位置。
+func (d *Desktop) scaleProportional(img image.Image, dstWidth, dstHeight int) (image.Image, int, int) {
+	srcWidth := img.Bounds().Dx()
+	srcHeight := img.Bounds().Dy()
+
+	if d.scaleMethod == scaleMethodStretch || srcWidth == 0 || srcHeight == 0 ||
+		dstWidth*srcHeight == srcWidth*dstHeight {
+		return resize.Resize(uint(dstWidth), uint(dstHeight), img, resize.Lanczos3), 0, 0
+	}
+
+	// 目标区域比图片更宽
+	dstWider := dstWidth*srcHeight > srcWidth*dstHeight
+
+	srcX, srcY, srcW, srcH := 0, 0, srcWidth, srcHeight
+	dstX, dstY, dstW, dstH := 0, 0, dstWidth, dstHeight
+
+	cropV := func() {
+		srcH = dstHeight * srcWidth / dstWidth
+		srcY = getAlignOffset(d.vAlign, srcHeight, srcH)
+	}
+	cropH := func() {
+		srcW = dstWidth * srcHeight / dstHeight
+		srcX = getAlignOffset(d.hAlign, srcWidth, srcW)
+	}
+	padH := func() {
+		dstW = srcWidth * dstHeight / srcHeight
+		dstX = getAlignOffset(d.hAlign, dstWidth, dstW)
+	}
+	padV := func() {
+		dstH = srcHeight * dstWidth / srcWidth
+		dstY = getAlignOffset(d.vAlign, dstHeight, dstH)
+	}
+
+	switch d.scaleMethod {
+	case scaleMethodCrop:
+		if dstWider {
+			cropV()
+		} else {
+			cropH()
+		}
+	case scaleMethodPadding:
+		if dstWider {
+			padH()
+		} else {
+			padV()
+		}
+	case scaleMethodFitWidth:
+		if dstWider {
+			cropV()
+		} else {
+			padV()
+		}
+	case scaleMethodFitHeight:
+		if dstWider {
+			padH()
+		} else {
+			cropH()
+		}
+	default:
+		log.Printf("unknown desktop-image-scale-method %q\n", d.scaleMethod)
+	}
+
+	if si, ok := img.(subImager); ok {
+		min := img.Bounds().Min
+		img = si.SubImage(image.Rect(min.X+srcX, min.Y+srcY,
+			min.X+srcX+srcW, min.Y+srcY+srcH))
+	}
+	img = resize.Resize(uint(dstW), uint(dstH), img, resize.Lanczos3)
+	return img, dstX, dstY
+}
+
+func (d *Desktop) draw(n *Node, ctx *gg.Context, ec *EvalContext) {
+	x := n.getLeft().Eval(ec)
+	y := n.getTop().Eval(ec)
+	width := n.getWidth().Eval(ec)
+	height := n.getHeight().Eval(ec)
+
+	ctx.SetColor(parseColor(d.color))
+	ctx.DrawRectangle(x, y, width, height)
+	ctx.Fill()
+
+	if d.image == "" {
+		return
+	}
+
+	img, err := gg.LoadImage(getResourceFile(d.image))
+	if err != nil {
+		log.Println("WARN: load desktop image:", err)
+		return
+	}
+
+	img, dx, dy := d.scaleProportional(img, int(width), int(height))
+	ctx.DrawImage(img, int(x)+dx, int(y)+dy)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	tt "github.com/electricface/grub-theme-viewer/themetxt"
+)
+
+const editorHelpMessage = "Minimum Emacs-like screen editing is supported. " +
+	"TAB lists completions. Press Ctrl-x or F10 to boot, " +
+	"Ctrl-c or F2 for a command-line or ESC to discard edits " +
+	"and return to the GRUB menu."
+
+// 边框使用的制表符
+const (
+	borderHLine    = '─'
+	borderVLine    = '│'
+	borderCornerUL = '┌'
+	borderCornerUR = '┐'
+	borderCornerLL = '└'
+	borderCornerLR = '┘'
+)
+
+// quoteArg 参考 grub 的 setparams_prefix，用单引号包围参数
+func quoteArg(arg string) string {
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+}
+
+// getEditorLines 返回编辑器中的文字，与 grub 的 menu_sourcecode 一致，
+// 第一行是 setparams 和菜单项的标题。
+func (e *menuEntry) getEditorLines() []string {
+	lines := []string{"setparams " + quoteArg(e.title), ""}
+	return append(lines, e.script...)
+}
+
+// termGrid 是终端屏幕上的字符网格
+type termGrid [][]rune
+
+func newTermGrid(columns, rows int) termGrid {
+	grid := make(termGrid, rows)
+	for i := range grid {
+		grid[i] = []rune(strings.Repeat(" ", columns))
+	}
+	return grid
+}
+
+func (g termGrid) put(x, y int, str string) {
+	if y < 0 || y >= len(g) {
+		return
+	}
+	for _, r := range str {
+		if x >= 0 && x < len(g[y]) {
+			g[y][x] = r
+		}
+		x++
+	}
+}
+
+func (g termGrid) lines() []string {
+	lines := make([]string, len(g))
+	for i, row := range g {
+		lines[i] = strings.TrimRight(string(row), " ")
+	}
+	return lines
+}
+
+// getEditorScreen 参考 grub 的 grub_menu_init_page 和 menu_entry.c，
+// 返回编辑菜单项时的屏幕内容。
+func getEditorScreen(entry *menuEntry) termScreenFunc {
+	return func(columns, rows int) *termScreen {
+		grid := newTermGrid(columns, rows)
+		for i, line := range getBannerLines(columns) {
+			grid.put(0, i, line)
+		}
+
+		// 1 列空白和 1 列边框
+		firstEntryX := 2
+		entryWidth := columns - 5
+		// 2 行空行，1 行版本信息和 1 行上边框
+		firstEntryY := 4
+
+		helpLines := wrapText(gettextGrub(editorHelpMessage), columns, 6)
+		// 减去下边框，帮助信息前的空行，2 行倒计时和最后的空行
+		numEntries := rows - firstEntryY - 1 - 1 - 2 - 1 - len(helpLines)
+		if numEntries < 3 || entryWidth < 10 {
+			log.Println("WARN: terminal window is too small for the editor")
+			return &termScreen{lines: grid.lines(), cursorX: -1, cursorY: -1}
+		}
+
+		// 边框
+		hLine := strings.Repeat(string(borderHLine), entryWidth+1)
+		grid.put(firstEntryX-1, firstEntryY-1,
+			string(borderCornerUL)+hLine+string(borderCornerUR))
+		for i := 0; i < numEntries; i++ {
+			grid.put(firstEntryX-1, firstEntryY+i, string(borderVLine))
+			grid.put(firstEntryX+entryWidth+1, firstEntryY+i, string(borderVLine))
+		}
+		grid.put(firstEntryX-1, firstEntryY+numEntries,
+			string(borderCornerLL)+hLine+string(borderCornerLR))
+
+		// 超过宽度的行折到下一行，行尾显示 \
+		y := firstEntryY
+	loop:
+		for _, line := range entry.getEditorLines() {
+			parts := splitLine(line, entryWidth)
+			for i, part := range parts {
+				if y >= firstEntryY+numEntries {
+					break loop
+				}
+				grid.put(firstEntryX, y, part)
+				if i < len(parts)-1 {
+					grid.put(firstEntryX+entryWidth, y, `\`)
+				}
+				y++
+			}
+		}
+
+		// 帮助信息
+		y = firstEntryY + numEntries + 2
+		for _, line := range helpLines {
+			grid.put(0, y, line)
+			y++
+		}
+
+		// 光标在第一行的开头
+		return &termScreen{
+			lines:   grid.lines(),
+			cursorX: firstEntryX,
+			cursorY: firstEntryY,
+		}
+	}
+}
+
+// getEditEntry 返回当前菜单中要编辑的菜单项
+func getEditEntry() *menuEntry {
+	entries := getCurrentMenuEntries()
+	if optEditEntry < 0 || optEditEntry >= len(entries) {
+		log.Fatalf("invalid entry index %d", optEditEntry)
+	}
+	return entries[optEditEntry]
+}
+
+// editorToNodeTree 返回编辑菜单项界面的节点树
+func editorToNodeTree(theme *tt.Theme) *Node {
+	root := &Node{}
+	terminal := newTerminal(theme)
+	root.addChild(terminal.toNode(root, getEditorScreen(getEditEntry())))
+	return root
+}
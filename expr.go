@@ -14,6 +14,7 @@ const (
 	OpSub    // 减
 	OpMul    // 乘
 	OpDiv    // 除法
+	OpMax    // 最大值
 )
 
 var opFuncMap = map[Op]func(a, b float64) float64{
@@ -29,6 +30,7 @@ var opFuncMap = map[Op]func(a, b float64) float64{
 	OpDiv: func(a, b float64) float64 {
 		return a / b
 	},
+	OpMax: math.Max,
 }
 
 var opStrMap = map[Op]string{
@@ -36,6 +38,7 @@ var opStrMap = map[Op]string{
 	OpSub: "-",
 	OpMul: "*",
 	OpDiv: "/",
+	OpMax: "max",
 }
 
 type BinOp struct {
@@ -57,6 +60,9 @@ func (e BinOp) Eval(ec *EvalContext) float64 {
 func (e BinOp) ExprString() string {
 	left := e.Left.ExprString()
 	right := e.Right.ExprString()
+	if e.Op == OpMax {
+		return fmt.Sprintf("max(%s, %s)", left, right)
+	}
 	return fmt.Sprintf("(%s %s %s)", left, opStrMap[e.Op], right)
 }
 
@@ -140,3 +146,11 @@ func div(a, b Expr) Expr {
 		Right: b,
 	}
 }
+
+func maxOf(a, b Expr) Expr {
+	return BinOp{
+		Left:  a,
+		Op:    OpMax,
+		Right: b,
+	}
+}
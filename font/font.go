@@ -396,6 +396,18 @@ func parseCharInfo(r io.Reader) (*CharInfo, error) {
 	return &d, nil
 }
 
+// MeasureString 返回字符串绘制后的宽度，与 grub_font_get_string_width 一致
+func (f *Face) MeasureString(s string) int {
+	width := 0
+	for _, r := range s {
+		advance, ok := f.GlyphAdvance(r)
+		if ok {
+			width += advance.Round()
+		}
+	}
+	return width
+}
+
 func (f *Face) Height() int {
 	return f.Ascent + f.Descent
 }
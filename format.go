@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	tt "github.com/electricface/grub-theme-viewer/themetxt"
+)
+
+// fmtOptions 是 fmt 子命令的参数
+type fmtOptions struct {
+	list  bool
+	diff  bool
+	write bool
+}
+
+// runFmt 是 fmt 子命令，参考 gofmt 把 theme.txt 改写为规范格式，
+// 参数是文件或者主题目录，没有参数时读取标准输入。出错时退出码为 2。
+func runFmt(args []string) {
+	var opts fmtOptions
+	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
+	fs.BoolVar(&opts.list, "l", false, "list files whose formatting differs from canonical format")
+	fs.BoolVar(&opts.diff, "d", false, "display diffs instead of rewriting files")
+	fs.BoolVar(&opts.write, "w", false, "write result to source file instead of stdout")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: grub-theme-viewer fmt [-l] [-d] [-w] [path ...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	exitCode := 0
+	if fs.NArg() == 0 {
+		if opts.write {
+			fmt.Fprintln(os.Stderr, "error: cannot use -w with standard input")
+			os.Exit(2)
+		}
+		data, err := ioutil.ReadAll(os.Stdin)
+		if err == nil {
+			err = formatFile("<standard input>", data, &opts)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 2
+		}
+		os.Exit(exitCode)
+	}
+
+	for _, path := range fs.Args() {
+		// 目录中的 theme.txt
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			path = filepath.Join(path, "theme.txt")
+		}
+		data, err := ioutil.ReadFile(path)
+		if err == nil {
+			err = formatFile(path, data, &opts)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 2
+		}
+	}
+	os.Exit(exitCode)
+}
+
+// formatFile 格式化一个文件，按照 opts 输出结果或者写回文件
+func formatFile(filename string, data []byte, opts *fmtOptions) error {
+	theme, err := tt.ParseTheme(filename, data)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	theme.Format(&buf)
+	res := buf.Bytes()
+
+	if !bytes.Equal(data, res) {
+		if opts.list {
+			fmt.Println(filename)
+		}
+		if opts.write {
+			info, err := os.Stat(filename)
+			if err != nil {
+				return err
+			}
+			err = ioutil.WriteFile(filename, res, info.Mode().Perm())
+			if err != nil {
+				return err
+			}
+		}
+		if opts.diff {
+			d, err := diffFile(filename, data, res)
+			if err != nil {
+				return fmt.Errorf("computing diff: %s", err)
+			}
+			fmt.Printf("diff -u %s %s\n", filepath.ToSlash(filename+".orig"), filepath.ToSlash(filename))
+			os.Stdout.Write(d)
+		}
+	}
+
+	if !opts.list && !opts.write && !opts.diff {
+		os.Stdout.Write(res)
+	}
+	return nil
+}
+
+// diffFile 使用 diff 命令比较格式化前后的内容
+func diffFile(filename string, b1, b2 []byte) ([]byte, error) {
+	f1, err := writeTempFile("theme-fmt", b1)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f1)
+
+	f2, err := writeTempFile("theme-fmt", b2)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f2)
+
+	label := filepath.ToSlash(filename)
+	data, err := exec.Command("diff", "-u", "-L", label+".orig", "-L", label, f1, f2).CombinedOutput()
+	if len(data) > 0 {
+		// 有差异时 diff 的退出码为 1
+		return data, nil
+	}
+	return data, err
+}
+
+func writeTempFile(prefix string, data []byte) (string, error) {
+	file, err := ioutil.TempFile("", prefix)
+	if err != nil {
+		return "", err
+	}
+	_, err = file.Write(data)
+	if err1 := file.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
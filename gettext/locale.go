@@ -0,0 +1,56 @@
+package gettext
+
+import "strings"
+
+const (
+	componentCodeset = 1 << iota
+	componentTerritory
+	componentModifier
+)
+
+// explodeLocale 把 language[_territory][.codeset][@modifier] 形式的 locale 分开，
+// mask 记录有哪些部分。
+func explodeLocale(locale string) (language, territory, codeset, modifier string, mask int) {
+	if idx := strings.IndexByte(locale, '@'); idx >= 0 {
+		modifier = locale[idx:]
+		locale = locale[:idx]
+		mask |= componentModifier
+	}
+	if idx := strings.IndexByte(locale, '.'); idx >= 0 {
+		codeset = locale[idx:]
+		locale = locale[:idx]
+		mask |= componentCodeset
+	}
+	if idx := strings.IndexByte(locale, '_'); idx >= 0 {
+		territory = locale[idx:]
+		locale = locale[:idx]
+		mask |= componentTerritory
+	}
+	language = locale
+	return
+}
+
+// GetLocaleVariants 参考 glib 的 g_get_locale_variants，返回查找翻译时依次尝试的 locale，
+// 例如 zh_CN.UTF-8 依次是 zh_CN.UTF-8、zh_CN、zh.UTF-8 和 zh。
+func GetLocaleVariants(locale string) []string {
+	language, territory, codeset, modifier, mask := explodeLocale(locale)
+	var variants []string
+	for i := mask; i >= 0; i-- {
+		// 只组合 locale 中有的部分
+		if i&^mask != 0 {
+			continue
+		}
+		variant := language
+		if i&componentTerritory != 0 {
+			variant += territory
+		}
+		if i&componentCodeset != 0 {
+			variant += codeset
+		}
+		if i&componentModifier != 0 {
+			variant += modifier
+		}
+		variants = append(variants, variant)
+	}
+	return variants
+}
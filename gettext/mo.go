@@ -0,0 +1,129 @@
+// Package gettext 读取 GNU gettext 的 .mo 文件
+package gettext
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	moMagicLE = 0x950412de
+	moMagicBE = 0xde120495
+)
+
+// Catalog 是 .mo 文件中的翻译，msgid 对应 msgstr
+type Catalog struct {
+	messages map[string]string
+}
+
+// LoadFile 读取 .mo 文件
+func LoadFile(filename string) (*Catalog, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+var errInvalidMo = errors.New("invalid mo file")
+
+// Parse 解析 .mo 文件的内容。有复数形式时只使用单数形式，
+// 带上下文的 msgid 保留 "上下文\x04msgid" 的形式。
+func Parse(data []byte) (*Catalog, error) {
+	if len(data) < 20 {
+		return nil, errInvalidMo
+	}
+
+	var order binary.ByteOrder
+	switch binary.LittleEndian.Uint32(data) {
+	case moMagicLE:
+		order = binary.LittleEndian
+	case moMagicBE:
+		order = binary.BigEndian
+	default:
+		return nil, errInvalidMo
+	}
+
+	// 只支持主版本号 0
+	revision := order.Uint32(data[4:])
+	if revision>>16 != 0 {
+		return nil, errors.New("unsupported mo file revision")
+	}
+
+	num := order.Uint32(data[8:])
+	origTable := order.Uint32(data[12:])
+	transTable := order.Uint32(data[16:])
+
+	// getString 读取字符串表中的第 i 个字符串
+	getString := func(table, i uint32) (string, error) {
+		pos := uint64(table) + uint64(i)*8
+		if pos+8 > uint64(len(data)) {
+			return "", errInvalidMo
+		}
+		length := uint64(order.Uint32(data[pos:]))
+		offset := uint64(order.Uint32(data[pos+4:]))
+		if offset+length > uint64(len(data)) {
+			return "", errInvalidMo
+		}
+		return string(data[offset : offset+length]), nil
+	}
+
+	// 先检查两个表都在文件中，再按 num 分配空间，避免损坏的文件给出很大的 num
+	tableSize := uint64(num) * 8
+	if uint64(origTable)+tableSize > uint64(len(data)) ||
+		uint64(transTable)+tableSize > uint64(len(data)) {
+		return nil, errInvalidMo
+	}
+
+	c := &Catalog{
+		messages: make(map[string]string, num),
+	}
+	for i := uint32(0); i < num; i++ {
+		msgid, err := getString(origTable, i)
+		if err != nil {
+			return nil, err
+		}
+		msgstr, err := getString(transTable, i)
+		if err != nil {
+			return nil, err
+		}
+
+		// 空的 msgid 对应的是文件头
+		if msgid == "" {
+			continue
+		}
+		if idx := strings.IndexByte(msgid, 0); idx >= 0 {
+			msgid = msgid[:idx]
+		}
+		if idx := strings.IndexByte(msgstr, 0); idx >= 0 {
+			msgstr = msgstr[:idx]
+		}
+		if msgstr == "" {
+			continue
+		}
+		c.messages[msgid] = msgstr
+	}
+	return c, nil
+}
+
+// Gettext 返回 msgid 的翻译，没有翻译时返回 msgid，c 可以是 nil
+func (c *Catalog) Gettext(msgid string) string {
+	if c == nil {
+		return msgid
+	}
+	msgstr, ok := c.messages[msgid]
+	if !ok {
+		return msgid
+	}
+	return msgstr
+}
+
+// Len 返回翻译的数量
+func (c *Catalog) Len() int {
+	if c == nil {
+		return 0
+	}
+	return len(c.messages)
+}
@@ -0,0 +1,129 @@
+package gettext
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// buildMo 按 order 生成 .mo 文件，msgid 需要按顺序排列
+func buildMo(order binary.ByteOrder, pairs [][2]string) []byte {
+	const headerSize = 28
+	num := uint32(len(pairs))
+	origTable := uint32(headerSize)
+	transTable := origTable + num*8
+	offset := transTable + num*8
+
+	data := make([]byte, offset)
+	order.PutUint32(data[0:], moMagicLE)
+	order.PutUint32(data[8:], num)
+	order.PutUint32(data[12:], origTable)
+	order.PutUint32(data[16:], transTable)
+
+	for col, table := range []uint32{origTable, transTable} {
+		for i, pair := range pairs {
+			str := pair[col]
+			pos := table + uint32(i)*8
+			order.PutUint32(data[pos:], uint32(len(str)))
+			order.PutUint32(data[pos+4:], uint32(len(data)))
+			data = append(data, str...)
+			data = append(data, 0)
+		}
+	}
+	return data
+}
+
+var testPairs = [][2]string{
+	{"", "Content-Type: text/plain; charset=UTF-8\n"},
+	{"Booting in %d seconds", "%d 秒后启动"},
+	{"entry\x00entries", "菜单项\x00菜单项"},
+	{"menu\x04Exit", "退出"},
+	{"untranslated", ""},
+}
+
+func TestParse(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		c, err := Parse(buildMo(order, testPairs))
+		if err != nil {
+			t.Errorf("%v: %v", order, err)
+			continue
+		}
+		want := map[string]string{
+			"Booting in %d seconds": "%d 秒后启动",
+			"entry":                 "菜单项",
+			"menu\x04Exit":          "退出",
+		}
+		if !reflect.DeepEqual(c.messages, want) {
+			t.Errorf("%v: got %q, want %q", order, c.messages, want)
+		}
+		if got := c.Gettext("untranslated"); got != "untranslated" {
+			t.Errorf("%v: Gettext(untranslated) = %q", order, got)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	valid := buildMo(binary.LittleEndian, testPairs)
+
+	badMagic := append([]byte{}, valid...)
+	badMagic[0] = 0
+
+	badRevision := append([]byte{}, valid...)
+	binary.LittleEndian.PutUint32(badRevision[4:], 1<<16)
+
+	// 数量很大，表超出文件
+	hugeNum := append([]byte{}, valid...)
+	binary.LittleEndian.PutUint32(hugeNum[8:], 0xffffffff)
+
+	// 字符串超出文件
+	badOffset := append([]byte{}, valid...)
+	binary.LittleEndian.PutUint32(badOffset[28+8+4:], uint32(len(valid)))
+
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"empty", nil, "invalid mo file"},
+		{"header only", valid[:19], "invalid mo file"},
+		{"bad magic", badMagic, "invalid mo file"},
+		{"bad revision", badRevision, "unsupported mo file revision"},
+		{"huge num", hugeNum, "invalid mo file"},
+		{"truncated table", valid[:28+8*len(testPairs)+4], "invalid mo file"},
+		{"truncated string", valid[:len(valid)-3], "invalid mo file"},
+		{"bad offset", badOffset, "invalid mo file"},
+	}
+	for _, test := range tests {
+		_, err := Parse(test.data)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%s: expected error %q, got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func TestNilCatalog(t *testing.T) {
+	var c *Catalog
+	if c.Gettext("a") != "a" || c.Len() != 0 {
+		t.Error("nil catalog should return msgid")
+	}
+}
+
+func TestGetLocaleVariants(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   []string
+	}{
+		{"zh", []string{"zh"}},
+		{"zh_CN", []string{"zh_CN", "zh"}},
+		{"zh_CN.UTF-8", []string{"zh_CN.UTF-8", "zh_CN", "zh.UTF-8", "zh"}},
+		{"sr_RS@latin", []string{"sr_RS@latin", "sr@latin", "sr_RS", "sr"}},
+		{"de_DE.UTF-8@euro", []string{"de_DE.UTF-8@euro", "de_DE@euro", "de.UTF-8@euro",
+			"de@euro", "de_DE.UTF-8", "de_DE", "de.UTF-8", "de"}},
+	}
+	for _, test := range tests {
+		got := GetLocaleVariants(test.locale)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.locale, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+module github.com/electricface/grub-theme-viewer
+
+go 1.18
+
+require (
+	github.com/fogleman/gg v1.3.0
+	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646
+	golang.org/x/image v0.18.0
+)
+
+require github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
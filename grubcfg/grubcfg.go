@@ -0,0 +1,391 @@
+// Package grubcfg 从 grub.cfg 中读取菜单项
+package grubcfg
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Entry 对应 grub.cfg 中的 menuentry 或 submenu
+type Entry struct {
+	Title   string
+	Classes []string
+	ID      string
+	// 花括号中的脚本，按行分开
+	Script []string
+
+	Submenu bool
+	Entries []*Entry
+}
+
+// 最多嵌套 source 的层数，避免循环引用
+const maxSourceDepth = 16
+
+// 这些关键字之后的命令照常处理，循环体只读取一次。if、elif、else 和 fi 由 ifStack 处理。
+var keywords = map[string]bool{
+	"then":  true,
+	"while": true,
+	"until": true,
+	"for":   true,
+	"do":    true,
+	"done":  true,
+	"!":     true,
+}
+
+// ifStack 记录嵌套的 if，值为 true 表示已经过了第一个分支。
+// 条件不会计算，if 只读取第一个分支，elif 和 else 分支中的命令都跳过。
+// grub-mkconfig 生成的 41_custom 在 if 和 elif 分支中 source 同一个 custom.cfg，
+// 读取所有分支的话菜单项会重复。
+type ifStack []bool
+
+// skipping 判断当前是否在某一层 if 的第一个分支之后
+func (s ifStack) skipping() bool {
+	for _, v := range s {
+		if v {
+			return true
+		}
+	}
+	return false
+}
+
+// skipKeywords 去掉命令开头的关键字，同时更新 if 的状态
+func (s *ifStack) skipKeywords(words []*token) []*token {
+	for len(words) > 0 {
+		switch words[0].value {
+		case "if":
+			*s = append(*s, false)
+		case "elif", "else":
+			if len(*s) > 0 {
+				(*s)[len(*s)-1] = true
+			}
+		case "fi":
+			if len(*s) > 0 {
+				*s = (*s)[:len(*s)-1]
+			}
+		default:
+			if !keywords[words[0].value] {
+				return words
+			}
+		}
+		words = words[1:]
+	}
+	return words
+}
+
+type parser struct {
+	filename string
+	src      string
+	tokens   []*token
+	pos      int
+
+	// 最外层 grub.cfg 所在的目录
+	rootDir string
+	depth   int
+}
+
+// ParseFile 读取 grub.cfg，返回最外层的菜单项，submenu 中的菜单项在 Entries 中
+func ParseFile(filename string) ([]*Entry, error) {
+	rootDir := filepath.Dir(filename)
+	return parseFile(filename, rootDir, 0)
+}
+
+func parseFile(filename, rootDir string, depth int) ([]*Entry, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return parse(filename, string(content), rootDir, depth)
+}
+
+func parse(filename, src, rootDir string, depth int) ([]*Entry, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	p := &parser{
+		filename: filename,
+		src:      src,
+		tokens:   tokens,
+		rootDir:  rootDir,
+		depth:    depth,
+	}
+	return p.parseCommands(false)
+}
+
+func (p *parser) errorf(tok *token, format string, args ...interface{}) error {
+	line := 0
+	if tok != nil {
+		line = tok.line
+	} else if len(p.tokens) > 0 {
+		line = p.tokens[len(p.tokens)-1].line
+	}
+	return fmt.Errorf("%s:%d: %s", p.filename, line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) peek() *token {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+	return p.tokens[p.pos]
+}
+
+// parseCommands 处理命令直到文件结束，inBlock 为 true 时处理到 } 为止，} 由调用者读取。
+func (p *parser) parseCommands(inBlock bool) ([]*Entry, error) {
+	var entries []*Entry
+	var ifs ifStack
+	for {
+		tok := p.peek()
+		if tok == nil {
+			if inBlock {
+				return nil, p.errorf(nil, "missing }")
+			}
+			return entries, nil
+		}
+
+		switch tok.typ {
+		case tokenSep:
+			p.pos++
+			continue
+		case tokenRBrace:
+			if inBlock {
+				return entries, nil
+			}
+			return nil, p.errorf(tok, "unexpected }")
+		case tokenLBrace:
+			// 单独的 { ... } 块
+			p.pos++
+			children, err := p.parseCommands(true)
+			if err != nil {
+				return nil, err
+			}
+			p.pos++
+			if !ifs.skipping() {
+				entries = append(entries, children...)
+			}
+			continue
+		}
+
+		words := ifs.skipKeywords(p.readCommand())
+		if len(words) == 0 {
+			continue
+		}
+
+		switch words[0].value {
+		case "menuentry", "submenu":
+			// 跳过的分支中的菜单项也要读取，才能跳过它的花括号
+			entry, err := p.parseEntry(words)
+			if err != nil {
+				return nil, err
+			}
+			if !ifs.skipping() {
+				entries = append(entries, entry)
+			}
+
+		case "function":
+			// 跳过函数体
+			if _, _, err := p.readBlock(words[0]); err != nil {
+				return nil, err
+			}
+
+		case "source", ".":
+			if ifs.skipping() {
+				continue
+			}
+			if len(words) < 2 {
+				return nil, p.errorf(words[0], "%s: filename expected", words[0].value)
+			}
+			children, err := p.source(words[1].value)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, children...)
+		}
+	}
+}
+
+// readCommand 读取一条命令的单词，遇到分隔符或花括号时停止
+func (p *parser) readCommand() []*token {
+	var words []*token
+	for {
+		tok := p.peek()
+		if tok == nil || tok.typ != tokenWord {
+			return words
+		}
+		words = append(words, tok)
+		p.pos++
+	}
+}
+
+// readBlock 读取 { ... }，返回两个花括号
+func (p *parser) readBlock(cmd *token) (lBrace, rBrace *token, err error) {
+	lBrace = p.peek()
+	if lBrace == nil || lBrace.typ != tokenLBrace {
+		return nil, nil, p.errorf(cmd, "%s: missing {", cmd.value)
+	}
+	p.pos++
+
+	depth := 1
+	for {
+		tok := p.peek()
+		if tok == nil {
+			return nil, nil, p.errorf(lBrace, "%s: missing }", cmd.value)
+		}
+		p.pos++
+		switch tok.typ {
+		case tokenLBrace:
+			depth++
+		case tokenRBrace:
+			depth--
+			if depth == 0 {
+				return lBrace, tok, nil
+			}
+		}
+	}
+}
+
+// parseEntry 参考 grub 的 menuentry 命令的参数
+func (p *parser) parseEntry(words []*token) (*Entry, error) {
+	cmd := words[0]
+	entry := &Entry{
+		Submenu: cmd.value == "submenu",
+	}
+
+	args := words[1:]
+	noMoreOptions := false
+	hasTitle := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i].value
+		// grub-mkconfig 生成的 grub.cfg 中这个变量的值是 --id
+		if arg == "$menuentry_id_option" || arg == "${menuentry_id_option}" {
+			arg = "--id"
+		}
+		if noMoreOptions || !strings.HasPrefix(arg, "--") {
+			// 标题之后的参数由 setparams 使用
+			if !hasTitle {
+				entry.Title = arg
+				hasTitle = true
+			}
+			continue
+		}
+
+		name := arg
+		var value string
+		hasValue := false
+		if idx := strings.Index(arg, "="); idx > 0 {
+			name = arg[:idx]
+			value = arg[idx+1:]
+			hasValue = true
+		}
+
+		switch name {
+		case "--":
+			noMoreOptions = true
+			continue
+		case "--unrestricted":
+			continue
+		case "--class", "--users", "--hotkey", "--id", "--source":
+		default:
+			log.Printf("WARN: %s:%d: %s: unknown option %q\n", p.filename, args[i].line,
+				cmd.value, arg)
+			continue
+		}
+
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, p.errorf(args[i], "%s: option %s requires an argument",
+					cmd.value, name)
+			}
+			i++
+			value = args[i].value
+		}
+		switch name {
+		case "--class":
+			entry.Classes = append(entry.Classes, value)
+		case "--id":
+			entry.ID = value
+		}
+	}
+	if !hasTitle {
+		return nil, p.errorf(cmd, "%s: title expected", cmd.value)
+	}
+
+	if entry.Submenu {
+		lBrace := p.peek()
+		if lBrace == nil || lBrace.typ != tokenLBrace {
+			return nil, p.errorf(cmd, "%s: missing {", cmd.value)
+		}
+		p.pos++
+		children, err := p.parseCommands(true)
+		if err != nil {
+			return nil, err
+		}
+		rBrace := p.peek()
+		p.pos++
+		entry.Entries = children
+		entry.Script = splitScript(p.src[lBrace.end:rBrace.start])
+		return entry, nil
+	}
+
+	lBrace, rBrace, err := p.readBlock(cmd)
+	if err != nil {
+		return nil, err
+	}
+	entry.Script = splitScript(p.src[lBrace.end:rBrace.start])
+	return entry, nil
+}
+
+// splitScript 把花括号中的源码按行分开，去掉开头和结尾的空行
+func splitScript(body string) []string {
+	lines := strings.Split(strings.Replace(body, "\r\n", "\n", -1), "\n")
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// source 读取 source 命令引用的文件，文件不存在时和 grub 一样继续执行
+func (p *parser) source(path string) ([]*Entry, error) {
+	if p.depth >= maxSourceDepth {
+		return nil, fmt.Errorf("%s: source nested too deeply", p.filename)
+	}
+
+	filename, ok := p.resolvePath(path)
+	if !ok {
+		log.Printf("WARN: %s: source: file %q not found\n", p.filename, path)
+		return nil, nil
+	}
+	return parseFile(filename, p.rootDir, p.depth+1)
+}
+
+// resolvePath 在 grub.cfg 所在的目录中查找 source 的文件，
+// $prefix 和 $config_directory 都指向这个目录。
+func (p *parser) resolvePath(path string) (string, bool) {
+	for _, name := range []string{"prefix", "config_directory"} {
+		path = strings.Replace(path, "${"+name+"}", p.rootDir, -1)
+		path = strings.Replace(path, "$"+name, p.rootDir, -1)
+	}
+
+	var candidates []string
+	if filepath.IsAbs(path) {
+		candidates = append(candidates, path,
+			// 预览复制出来的 grub 目录时，绝对路径在这个目录中找
+			filepath.Join(p.rootDir, filepath.Base(path)))
+	} else {
+		candidates = append(candidates, filepath.Join(p.rootDir, path))
+	}
+
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if err == nil && !info.IsDir() {
+			return candidate, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,220 @@
+package grubcfg
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// describeEntries 每行列出一个菜单项的标题、class 和 id，子菜单中的菜单项缩进
+func describeEntries(buf *bytes.Buffer, entries []*Entry, indent string) {
+	for _, entry := range entries {
+		kind := "menuentry"
+		if entry.Submenu {
+			kind = "submenu"
+		}
+		fmt.Fprintf(buf, "%s%s %q", indent, kind, entry.Title)
+		if len(entry.Classes) > 0 {
+			fmt.Fprintf(buf, " class=%s", strings.Join(entry.Classes, ","))
+		}
+		if entry.ID != "" {
+			fmt.Fprintf(buf, " id=%s", entry.ID)
+		}
+		buf.WriteString("\n")
+		describeEntries(buf, entry.Entries, indent+"  ")
+	}
+}
+
+func parseString(t *testing.T, src, rootDir string) string {
+	t.Helper()
+	entries, err := parse("grub.cfg", src, rootDir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	describeEntries(&buf, entries, "")
+	return buf.String()
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "options",
+			src: `menuentry 'Deepin 20' --class deepin --class gnu-linux $menuentry_id_option 'gnulinux-simple' {
+	linux /vmlinuz
+}
+menuentry --class=windows "Windows Boot Manager (on /dev/sda1)" --unrestricted {
+	chainloader +1
+}
+menuentry -- --title-with-dashes { true; }
+`,
+			want: `menuentry "Deepin 20" class=deepin,gnu-linux id=gnulinux-simple
+menuentry "Windows Boot Manager (on /dev/sda1)" class=windows
+menuentry "--title-with-dashes"
+`,
+		},
+		{
+			name: "submenu",
+			src: `submenu 'Advanced options' ${menuentry_id_option} 'adv' {
+	menuentry 'Linux 5.10' { linux /a; }
+	submenu 'Older' {
+		menuentry 'Linux 4.19' { linux /b; }
+	}
+}
+menuentry 'UEFI Firmware Settings' { fwsetup; }
+`,
+			want: `submenu "Advanced options" id=adv
+  menuentry "Linux 5.10"
+  submenu "Older"
+    menuentry "Linux 4.19"
+menuentry "UEFI Firmware Settings"
+`,
+		},
+		{
+			name: "nested braces and functions",
+			src: `function load_video {
+	if [ x$feature_all_video_module = xy ]; then
+		insmod all_video
+	fi
+}
+menuentry 'a' {
+	if [ x$grub_platform = xxen ]; then insmod xzio; fi
+	echo '}' "{"
+}
+{
+	menuentry 'b' { true; }
+}
+`,
+			want: `menuentry "a"
+menuentry "b"
+`,
+		},
+		{
+			name: "first branch of if",
+			src: `if [ "${grub_platform}" = "efi" ]; then
+	menuentry 'efi' { fwsetup; }
+	if false; then menuentry 'efi nested' { true; }
+	else menuentry 'efi else' { true; }
+	fi
+elif [ -n "$x" ]; then
+	menuentry 'elif' { true; }
+else
+	menuentry 'else' { true; }
+	if true; then menuentry 'else nested' { true; }; fi
+fi
+menuentry 'after' { true; }
+while false; do menuentry 'loop' { true; }; done
+`,
+			want: `menuentry "efi"
+menuentry "efi nested"
+menuentry "after"
+menuentry "loop"
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := parseString(t, test.src, "")
+			if got != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestScript(t *testing.T) {
+	src := "menuentry 'a' {\r\n\tinsmod ext2\r\n\tlinux /vmlinuz ro\r\n}\nmenuentry 'b' { true; }"
+	entries, err := parse("grub.cfg", src, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"\tinsmod ext2", "\tlinux /vmlinuz ro"},
+		{" true; "},
+	}
+	for i, entry := range entries {
+		if strings.Join(entry.Script, "|") != strings.Join(want[i], "|") {
+			t.Errorf("entry %q: expected script %q, got %q", entry.Title, want[i], entry.Script)
+		}
+	}
+}
+
+func writeFile(t *testing.T, filename, content string) {
+	t.Helper()
+	err := ioutil.WriteFile(filename, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSourceCustomCfg 使用 grub-mkconfig 生成的 41_custom，custom.cfg 只读取一次
+func TestSourceCustomCfg(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "custom.cfg"), "menuentry 'custom' { true; }\n")
+	writeFile(t, filepath.Join(dir, "grub.cfg"), `menuentry 'linux' { true; }
+### BEGIN /etc/grub.d/41_custom ###
+if [ -f  ${config_directory}/custom.cfg ]; then
+  source ${config_directory}/custom.cfg
+elif [ -z "${config_directory}" -a -f  $prefix/custom.cfg ]; then
+  source $prefix/custom.cfg
+fi
+### END /etc/grub.d/41_custom ###
+. /boot/grub/custom.cfg
+source missing.cfg
+`)
+
+	entries, err := ParseFile(filepath.Join(dir, "grub.cfg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	describeEntries(&buf, entries, "")
+	// 最后的 . 命令按绝对路径找不到文件时，在 grub.cfg 所在的目录中找
+	want := `menuentry "linux"
+menuentry "custom"
+menuentry "custom"
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestSourceLoop(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "grub.cfg")
+	writeFile(t, filename, "source grub.cfg\n")
+	_, err := ParseFile(filename)
+	if err == nil || !strings.Contains(err.Error(), "source nested too deeply") {
+		t.Errorf("expected nesting error, got %v", err)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		src string
+		err string
+	}{
+		{"menuentry 'a' {\n  true\n", "grub.cfg:1: menuentry: missing }"},
+		{"menuentry {\n}", "grub.cfg:1: menuentry: title expected"},
+		{"\nmenuentry 'a'\n", "grub.cfg:2: menuentry: missing {"},
+		{"menuentry 'a' --class", "grub.cfg:1: menuentry: option --class requires an argument"},
+		{"submenu 'a' {\n", "grub.cfg:1: missing }"},
+		{"true\n}\n", "grub.cfg:2: unexpected }"},
+		{"source\n", "grub.cfg:1: source: filename expected"},
+		{"echo 'a\n", "grub.cfg: line 1: unterminated single quote"},
+	}
+	for _, test := range tests {
+		_, err := parse("grub.cfg", test.src, os.TempDir(), 0)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q: expected error %q, got %v", test.src, test.err, err)
+		}
+	}
+}
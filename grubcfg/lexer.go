@@ -0,0 +1,195 @@
+package grubcfg
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenType int
+
+const (
+	tokenWord tokenType = iota
+	// 换行或分号，命令之间的分隔
+	tokenSep
+	tokenLBrace
+	tokenRBrace
+)
+
+type token struct {
+	typ   tokenType
+	value string
+	// 在源码中的位置 [start, end)
+	start int
+	end   int
+	line  int
+}
+
+// lexer 参考 grub script 的词法，把源码分成单词，处理引号、转义和注释
+type lexer struct {
+	src    string
+	pos    int
+	line   int
+	tokens []*token
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r'
+}
+
+func isMetaChar(c byte) bool {
+	return isBlank(c) || c == '\n' || c == ';'
+}
+
+func lex(src string) ([]*token, error) {
+	l := &lexer{src: src, line: 1}
+	for {
+		l.skipBlank()
+		if l.pos >= len(l.src) {
+			break
+		}
+
+		c := l.src[l.pos]
+		switch {
+		case c == '\n' || c == ';':
+			l.emit(tokenSep, string(c), l.pos, l.pos+1)
+			if c == '\n' {
+				l.line++
+			}
+			l.pos++
+		case c == '#':
+			// 注释直到行尾
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			err := l.lexWord()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return l.tokens, nil
+}
+
+func (l *lexer) emit(typ tokenType, value string, start, end int) {
+	l.tokens = append(l.tokens, &token{
+		typ:   typ,
+		value: value,
+		start: start,
+		end:   end,
+		line:  l.line,
+	})
+}
+
+func (l *lexer) skipBlank() {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if isBlank(c) {
+			l.pos++
+		} else if c == '\\' && l.pos+1 < len(l.src) && l.src[l.pos+1] == '\n' {
+			// 续行
+			l.pos += 2
+			l.line++
+		} else {
+			break
+		}
+	}
+}
+
+func (l *lexer) lexWord() error {
+	start := l.pos
+	startLine := l.line
+	var buf strings.Builder
+	quoted := false
+
+loop:
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case isMetaChar(c):
+			break loop
+
+		case c == '\\':
+			l.pos++
+			if l.pos < len(l.src) {
+				if l.src[l.pos] == '\n' {
+					l.line++
+				} else {
+					// 转义的 { 和 } 也是单词
+					quoted = true
+					buf.WriteByte(l.src[l.pos])
+				}
+				l.pos++
+			}
+
+		case c == '\'':
+			quoted = true
+			end := strings.IndexByte(l.src[l.pos+1:], '\'')
+			if end < 0 {
+				return fmt.Errorf("line %d: unterminated single quote", startLine)
+			}
+			str := l.src[l.pos+1 : l.pos+1+end]
+			buf.WriteString(str)
+			l.line += strings.Count(str, "\n")
+			l.pos += end + 2
+
+		case c == '"':
+			quoted = true
+			l.pos++
+			closed := false
+			for l.pos < len(l.src) {
+				c := l.src[l.pos]
+				if c == '"' {
+					closed = true
+					l.pos++
+					break
+				}
+				if c == '\\' && l.pos+1 < len(l.src) {
+					next := l.src[l.pos+1]
+					switch next {
+					case '\\', '"', '$':
+						buf.WriteByte(next)
+						l.pos += 2
+						continue
+					case '\n':
+						l.line++
+						l.pos += 2
+						continue
+					}
+				}
+				if c == '\n' {
+					l.line++
+				}
+				buf.WriteByte(c)
+				l.pos++
+			}
+			if !closed {
+				return fmt.Errorf("line %d: unterminated double quote", startLine)
+			}
+
+		default:
+			buf.WriteByte(c)
+			l.pos++
+		}
+	}
+
+	value := buf.String()
+	typ := tokenWord
+	// 单独的 { 和 } 才是花括号，${root} 这样的还是单词
+	if !quoted {
+		switch value {
+		case "{":
+			typ = tokenLBrace
+		case "}":
+			typ = tokenRBrace
+		}
+	}
+	l.tokens = append(l.tokens, &token{
+		typ:   typ,
+		value: value,
+		start: start,
+		end:   l.pos,
+		line:  startLine,
+	})
+	return nil
+}
@@ -0,0 +1,71 @@
+package grubcfg
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// describeTokens 把单词列出来，分隔符写作 ;，花括号写作 { 和 }，单词加上引号
+func describeTokens(tokens []*token) string {
+	var items []string
+	for _, tok := range tokens {
+		switch tok.typ {
+		case tokenWord:
+			items = append(items, fmt.Sprintf("%d:%q", tok.line, tok.value))
+		case tokenSep:
+			items = append(items, ";")
+		case tokenLBrace:
+			items = append(items, "{")
+		case tokenRBrace:
+			items = append(items, "}")
+		}
+	}
+	return strings.Join(items, " ")
+}
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"set a=b", `1:"set" 1:"a=b"`},
+		{"a ; b\nc", `1:"a" ; 1:"b" ; 2:"c"`},
+		{"menuentry 'Deepin 20' {\n}", `1:"menuentry" 1:"Deepin 20" { ; }`},
+		{`echo "a \"b\" \\ \$x \n"`, `1:"echo" 1:"a \"b\" \\ $x \\n"`},
+		{`echo 'a\b' "c"'d'e`, `1:"echo" 1:"a\\b" 1:"cde"`},
+		{`echo a\ b \{ }`, `1:"echo" 1:"a b" 1:"{" }`},
+		{"echo ${root} '{' \"}\"", `1:"echo" 1:"${root}" 1:"{" 1:"}"`},
+		{"a # comment ; b\nc", `1:"a" ; 2:"c"`},
+		{"a \\\n  b\nc", `1:"a" 2:"b" ; 3:"c"`},
+		{"echo 'x\ny' z", `1:"echo" 1:"x\ny" 2:"z"`},
+		{"a\r\nb", `1:"a" ; 2:"b"`},
+	}
+	for _, test := range tests {
+		tokens, err := lex(test.src)
+		if err != nil {
+			t.Errorf("%q: %v", test.src, err)
+			continue
+		}
+		got := describeTokens(tokens)
+		if got != test.want {
+			t.Errorf("%q:\ngot:  %s\nwant: %s", test.src, got, test.want)
+		}
+	}
+}
+
+func TestLexError(t *testing.T) {
+	tests := []struct {
+		src string
+		err string
+	}{
+		{"echo 'abc", "line 1: unterminated single quote"},
+		{"echo\necho \"abc\n", "line 2: unterminated double quote"},
+	}
+	for _, test := range tests {
+		_, err := lex(test.src)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q: expected error %q, got %v", test.src, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/electricface/grub-theme-viewer/gettext"
+)
+
+// grub 域的翻译，没有指定语言时是 nil
+var globalCatalog *gettext.Catalog
+
+// getCatalogFiles 返回语言对应的 .mo 文件，先找 grub 的 locale 目录，
+// 再找系统中 grub 域的翻译。
+func getCatalogFiles(lang string) []string {
+	var files []string
+	for _, variant := range gettext.GetLocaleVariants(lang) {
+		files = append(files,
+			filepath.Join(optLocaleDir, variant+".mo"),
+			filepath.Join("/usr/share/locale", variant, "LC_MESSAGES", "grub.mo"))
+	}
+	return files
+}
+
+// loadCatalog 读取语言的翻译，找不到时使用英文
+func loadCatalog(lang string) {
+	for _, file := range getCatalogFiles(lang) {
+		if _, err := os.Stat(file); err != nil {
+			continue
+		}
+		catalog, err := gettext.LoadFile(file)
+		if err != nil {
+			log.Println("WARN:", err)
+			continue
+		}
+		log.Printf("load catalog %s, %d messages\n", file, catalog.Len())
+		globalCatalog = catalog
+		return
+	}
+	log.Printf("WARN: no grub translation found for language %q\n", lang)
+}
+
+// gettextGrub 相当于 grub 中的 _()
+func gettextGrub(msgid string) string {
+	return globalCatalog.Gettext(msgid)
+}
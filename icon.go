@@ -0,0 +1,83 @@
+package main
+
+import (
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// iconManager 参考 grub 的 icon_manager，按菜单项的 class 查找图标
+type iconManager struct {
+	// class 对应的图标文件，没有图标时是空字符串
+	cache map[string]string
+	// 没有图标的 class
+	missing map[string]bool
+	// 所有 class 都没有图标的菜单项
+	missingEntries map[string][]string
+}
+
+var globalIconManager = newIconManager()
+
+func newIconManager() *iconManager {
+	return &iconManager{
+		cache:          make(map[string]string),
+		missing:        make(map[string]bool),
+		missingEntries: make(map[string][]string),
+	}
+}
+
+// getIconByClass 返回 icons/<class>.png，文件不存在时返回空字符串
+func (m *iconManager) getIconByClass(class string) string {
+	file, ok := m.cache[class]
+	if ok {
+		return file
+	}
+
+	file = "icons/" + class + ".png"
+	_, err := os.Stat(getResourceFile(file))
+	if err != nil {
+		file = ""
+		m.missing[class] = true
+	}
+	m.cache[class] = file
+	return file
+}
+
+// getIcon 参考 grub_gfxmenu_icon_manager_get_icon，
+// 按顺序尝试菜单项的每个 class，使用第一个找到的图标。
+func (m *iconManager) getIcon(entry *menuEntry) string {
+	for _, class := range entry.classes {
+		file := m.getIconByClass(class)
+		if file != "" {
+			return file
+		}
+	}
+	if len(entry.classes) > 0 {
+		m.missingEntries[entry.title] = entry.classes
+	}
+	return ""
+}
+
+// reportMissing 列出没有图标的 class 和菜单项
+func (m *iconManager) reportMissing() {
+	if len(m.missing) == 0 {
+		return
+	}
+	var classes []string
+	for class := range m.missing {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	log.Println("classes without icon:", strings.Join(classes, ", "))
+
+	var titles []string
+	for title := range m.missingEntries {
+		titles = append(titles, title)
+	}
+	sort.Strings(titles)
+	for _, title := range titles {
+		log.Printf("WARN: no icon for entry %q, classes: %s\n", title,
+			strings.Join(m.missingEntries[title], " "))
+	}
+}
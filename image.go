@@ -0,0 +1,43 @@
+package main
+
+import (
+	"log"
+
+	tt "github.com/electricface/grub-theme-viewer/themetxt"
+
+	"github.com/fogleman/gg"
+)
+
+type Image struct {
+	CompCommon
+	file string
+}
+
+func newImage(comp *tt.Component) *Image {
+	img := &Image{}
+	img.node = &Node{}
+	img.fillCommonOptions(comp)
+	img.file, _ = comp.GetPropString("file")
+	return img
+}
+
+func compImageToNode(comp *tt.Component, parent *Node) *Node {
+	img := newImage(comp)
+
+	// 没有设置宽高时使用图片的原始大小
+	bitmap, err := gg.LoadImage(getResourceFile(img.file))
+	if err != nil {
+		log.Println("WARN: load image:", err)
+	} else {
+		img.node.prefWidth = AbsNum(bitmap.Bounds().Dx())
+		img.node.prefHeight = AbsNum(bitmap.Bounds().Dy())
+	}
+
+	img.node.draw = func(n *Node, ctx *gg.Context, ec *EvalContext) {
+		err := n.drawImage(ctx, ec, img.file)
+		if err != nil {
+			log.Println("WARN: draw image:", err)
+		}
+	}
+	return img.node
+}
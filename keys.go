@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	tt "github.com/electricface/grub-theme-viewer/themetxt"
+
+	"github.com/fogleman/gg"
+)
+
+// grub 中 Page Up 和 Page Down 移动的菜单项数量，即 GRUB_MENU_PAGE_SIZE
+const menuPageSize = 10
+
+// menuNavigator 参考 grub 的 run_menu，按键改变选中的菜单项和进入的子菜单
+type menuNavigator struct {
+	theme *tt.Theme
+	root  *Node
+	// 返回最外层菜单时选中的菜单项
+	topDefault int
+}
+
+func newMenuNavigator(theme *tt.Theme) *menuNavigator {
+	nav := &menuNavigator{
+		theme: theme,
+	}
+	if optNav == "" {
+		nav.topDefault = globalSelected
+	}
+	nav.buildTree()
+	return nav
+}
+
+// buildTree 进入或离开子菜单时重新创建节点树，和 grub 一样菜单从头开始显示
+func (nav *menuNavigator) buildTree() {
+	nav.root = themeToNodeTree(nav.theme, optScreenWidth, optScreenHeight)
+	// 画背景
+	desktop := newDesktop(nav.theme)
+	nav.root.draw = desktop.draw
+}
+
+func (nav *menuNavigator) setSelected(idx int) {
+	globalSelected = idx
+	// 选中的菜单项改变后，显示的菜单项可能要滚动
+	ec := newEvalContent()
+	ec.setUnknown("screen-width", float64(optScreenWidth))
+	ec.setUnknown("screen-height", float64(optScreenHeight))
+	for _, bm := range globalBootMenus {
+		bm.selected = idx
+		bm.layoutItems(ec)
+	}
+}
+
+// pressKey 处理一个按键，进入子菜单之外不会启动菜单项
+func (nav *menuNavigator) pressKey(key string) error {
+	entries := getCurrentMenuEntries()
+	num := len(entries)
+	selected := globalSelected
+
+	switch key {
+	case "up":
+		if selected > 0 {
+			nav.setSelected(selected - 1)
+		}
+	case "down":
+		if selected < num-1 {
+			nav.setSelected(selected + 1)
+		}
+	case "home":
+		nav.setSelected(0)
+	case "end":
+		if num > 0 {
+			nav.setSelected(num - 1)
+		}
+	case "page-up":
+		nav.setSelected(maxInt(selected-menuPageSize, 0))
+	case "page-down":
+		if num > 0 {
+			nav.setSelected(minInt(selected+menuPageSize, num-1))
+		}
+
+	case "enter", "enter-submenu":
+		if num == 0 {
+			return nil
+		}
+		entry := entries[selected]
+		if !entry.submenu {
+			log.Printf("WARN: booting %q is not simulated\n", entry.title)
+			return nil
+		}
+		globalSubmenus = append(globalSubmenus, entry)
+		globalSelected = 0
+		nav.buildTree()
+
+	case "esc", "back":
+		// 最外层菜单中 ESC 不起作用
+		if len(globalSubmenus) == 0 {
+			return nil
+		}
+		globalSubmenus = globalSubmenus[:len(globalSubmenus)-1]
+		globalSelected = 0
+		if len(globalSubmenus) == 0 {
+			globalSelected = nav.topDefault
+		}
+		nav.buildTree()
+
+	default:
+		return fmt.Errorf("unknown key %q", key)
+	}
+	return nil
+}
+
+// getFrameFile 返回第 idx 帧的 PNG 文件名，out.png 的第 1 帧是 out-001.png
+func getFrameFile(output string, idx int) string {
+	ext := filepath.Ext(output)
+	return fmt.Sprintf("%s-%03d%s", strings.TrimSuffix(output, ext), idx, ext)
+}
+
+// drawKeyScript 依次按下按键，每一步画一帧，输出文件是 .gif 时保存为 GIF 动画，
+// 否则保存为编号的 PNG 文件。
+func drawKeyScript(theme *tt.Theme, ec *EvalContext) {
+	keys := strings.Fields(optKeys)
+	nav := newMenuNavigator(theme)
+	saveGIF := strings.EqualFold(filepath.Ext(optOutput), ".gif")
+	var w gifWriter
+
+	drawFrame := func(idx int) {
+		ctx := gg.NewContext(optScreenWidth, optScreenHeight)
+		nav.root.DrawTo(ctx, ec)
+		if saveGIF {
+			w.addFrame(ctx.Image(), 50)
+			return
+		}
+		err := ctx.SavePNG(getFrameFile(optOutput, idx))
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	drawFrame(0)
+	for i, key := range keys {
+		log.Printf("press key %d: %s\n", i+1, key)
+		err := nav.pressKey(key)
+		if err != nil {
+			log.Fatal(err)
+		}
+		// 按键后倒计时取消
+		ec.setUnknown("timeout-visible", 0)
+		drawFrame(i + 1)
+	}
+
+	if saveGIF {
+		err := w.save(optOutput)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
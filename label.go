@@ -5,6 +5,7 @@ import (
 	"image/color"
 	"strings"
 
+	"github.com/electricface/grub-theme-viewer/font"
 	tt "github.com/electricface/grub-theme-viewer/themetxt"
 	"github.com/fogleman/gg"
 )
@@ -23,28 +24,16 @@ func newLabel(comp *tt.Component) *Label {
 	l.node = &Node{}
 	l.fillCommonOptions(comp)
 
-	var ok bool
-	l.visible, ok = comp.GetPropBool("visible")
-	if !ok {
-		l.visible = true
-	}
+	l.visible, _ = comp.GetPropBool("visible")
 
 	l.text, _ = comp.GetPropString("text")
+	l.text = expandTextTemplate(l.text)
 
-	l.font, ok = comp.GetPropString("font")
-	if !ok {
-		l.font = "Unknown Regular 16"
-	}
+	l.font, _ = comp.GetPropString("font")
 
-	l.color, ok = comp.GetPropString("color")
-	if !ok {
-		l.color = "black"
-	}
+	l.color, _ = comp.GetPropString("color")
 
-	l.align, ok = comp.GetPropString("align")
-	if !ok {
-		l.align = "left"
-	}
+	l.align, _ = comp.GetPropString("align")
 
 	return l
 }
@@ -65,17 +54,32 @@ func parseAlign(str string) gg.Align {
 	return gg.AlignLeft
 }
 
-func (l *Label) getText() string {
+// getText id 为 __timeout__ 时，文字中的 %d 是剩余的秒数
+func (l *Label) getText(ec *EvalContext) string {
 	var text string
 	text = l.text
 	if l.id == "__timeout__" {
 		if strings.Contains(l.text, "%d") {
-			text = fmt.Sprintf(l.text, 5)
+			text = fmt.Sprintf(l.text, int(timeoutLeftExpr.Eval(ec)))
 		}
 	}
 	return text
 }
 
+// labelTextWidth 在求值时才计算文字的宽度，因为文字随倒计时变化
+type labelTextWidth struct {
+	l        *Label
+	fontFace *font.Face
+}
+
+func (e labelTextWidth) Eval(ec *EvalContext) float64 {
+	return float64(e.fontFace.MeasureString(e.l.getText(ec)))
+}
+
+func (e labelTextWidth) ExprString() string {
+	return fmt.Sprintf("textWidth(%q)", e.l.text)
+}
+
 func (l *Label) getAlign() gg.Align {
 	return parseAlign(l.align)
 }
@@ -83,11 +87,19 @@ func (l *Label) getAlign() gg.Align {
 func compLabelToNode(comp *tt.Component, parent *Node) *Node {
 	label := newLabel(comp)
 	label.node.draw = func(n *Node, ctx *gg.Context, ec *EvalContext) {
+		if !label.visible || label.isHiddenTimeout(ec) {
+			return
+		}
 
 		fontFace := getFont(label.font)
 		width := n.getWidth().Eval(ec)
-		n.drawText1(ctx, ec, label.getText(), label.getColor(), fontFace,
+		n.drawText1(ctx, ec, label.getText(ec), label.getColor(), fontFace,
 			width, label.getAlign())
 	}
+
+	// 参考 grub 的 label_get_minimal_size
+	fontFace := getFont(label.font)
+	label.node.prefWidth = labelTextWidth{l: label, fontFace: fontFace}
+	label.node.prefHeight = AbsNum(fontFace.Height())
 	return label.node
 }
@@ -27,28 +27,35 @@ type Node struct {
 	widthExpr  Expr
 	heightExpr Expr
 
+	// 组件的首选大小，宽或高为 0 时使用
+	prefWidth  Expr
+	prefHeight Expr
+
 	draw func(n *Node, ctx *gg.Context, ec *EvalContext)
+
+	// 节点对应的组件，标题等不是组件的节点为 nil
+	comp *tt.Component
 }
 
+// getLengthExpr 返回长度的表达式 abs + val * (rel / 100)，和 tt.Length 的
+// GetConvertFunc 的计算方法一致
 func getLengthExpr(l tt.Length, val Expr) Expr {
-	switch ll := l.(type) {
-	case tt.AbsNum:
-		return AbsNum(int(ll))
-	case tt.RelNum:
-		// (val * (ll  / 100))
-		return mul(val, div(AbsNum(int(ll)), AbsNum(100)))
-
-	case tt.CombinedNum:
-		a := mul(val, div(AbsNum(int(ll.Rel)), AbsNum(100)))
-		switch ll.Op {
-		case tt.CombinedNumSub:
-			return sub(a, AbsNum(ll.Abs))
-		case tt.CombinedNumAdd:
-			return add(a, AbsNum(ll.Abs))
-		}
+	abs, rel := tt.GetLengthParts(l)
+	if rel == 0 {
+		return AbsNum(abs)
 	}
-	panic("not expect")
-	return nil
+	// (val * (rel / 100))
+	relExpr := mul(val, div(AbsNum(rel), AbsNum(100)))
+	if abs == 0 {
+		return relExpr
+	}
+	return add(relExpr, AbsNum(abs))
+}
+
+// isZeroLength 判断长度是否为 0，grub 在宽或高为 0 时会使用组件的最小大小
+func isZeroLength(l tt.Length) bool {
+	abs, rel := tt.GetLengthParts(l)
+	return abs == 0 && rel == 0
 }
 
 func (n *Node) getLeft() Expr {
@@ -86,6 +93,10 @@ func (n *Node) getWidth() Expr {
 		return n.widthExpr
 	}
 
+	if n.prefWidth != nil && isZeroLength(n.width) {
+		return n.prefWidth
+	}
+
 	if n.parent == nil {
 		// root
 		return &Unknown{name: "screen-width"}
@@ -99,6 +110,10 @@ func (n *Node) getHeight() Expr {
 		return n.heightExpr
 	}
 
+	if n.prefHeight != nil && isZeroLength(n.height) {
+		return n.prefHeight
+	}
+
 	if n.parent == nil {
 		// root
 		return &Unknown{name: "screen-height"}
@@ -107,6 +122,33 @@ func (n *Node) getHeight() Expr {
 	return getLengthExpr(n.height, ph)
 }
 
+// nodeWidth 在求值时才获取节点的宽度，
+// 用于节点的大小在构造之后才由容器确定的情况。
+type nodeWidth struct {
+	n *Node
+}
+
+func (e nodeWidth) Eval(ec *EvalContext) float64 {
+	return e.n.getWidth().Eval(ec)
+}
+
+func (e nodeWidth) ExprString() string {
+	return e.n.getWidth().ExprString()
+}
+
+// nodeHeight 在求值时才获取节点的高度
+type nodeHeight struct {
+	n *Node
+}
+
+func (e nodeHeight) Eval(ec *EvalContext) float64 {
+	return e.n.getHeight().Eval(ec)
+}
+
+func (e nodeHeight) ExprString() string {
+	return e.n.getHeight().ExprString()
+}
+
 func (n *Node) addChild(child *Node) {
 	child.parent = n
 	n.Children = append(n.Children, child)
@@ -214,6 +256,27 @@ func (n *Node) drawStyleBox(ctx *gg.Context, ec *EvalContext, name string) {
 	y := int(n.getTop().Eval(ec))
 	width := int(n.getWidth().Eval(ec))
 	height := int(n.getHeight().Eval(ec))
+	drawStyleBoxRect(ctx, name, x, y, width, height)
+}
+
+// hasStyleBox 判断 styled box 是否至少有一个切片图片
+func hasStyleBox(name string) bool {
+	if name == "" {
+		return false
+	}
+	for part := styleBoxNW; part <= styleBoxSE; part++ {
+		img, _ := loadStyleBoxSlice(name, part)
+		if img != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func drawStyleBoxRect(ctx *gg.Context, name string, x, y, width, height int) {
+	if name == "" {
+		return
+	}
 
 	color1 := "#f9f806"
 	color2 := "#f97306"
@@ -296,6 +359,10 @@ func (n *Node) drawStyleBox(ctx *gg.Context, ec *EvalContext, name string) {
 		}
 	}
 
+	// 中间切片的大小，为 0 时 resize 会保持宽高比，所以不画
+	centerWidth := width - padLeft - padRight
+	centerHeight := height - padTop - padBottom
+
 	// ---
 	// draw
 	// nw
@@ -311,7 +378,7 @@ func (n *Node) drawStyleBox(ctx *gg.Context, ec *EvalContext, name string) {
 	}
 
 	// n
-	if imgN != nil {
+	if imgN != nil && centerWidth > 0 {
 		imgN = resize.Resize(uint(width-padLeft-padRight), uint(padTop),
 			imgN, resize.Lanczos3)
 		ctx.DrawImage(imgN, x+padLeft, y)
@@ -337,7 +404,7 @@ func (n *Node) drawStyleBox(ctx *gg.Context, ec *EvalContext, name string) {
 	}
 
 	// w
-	if imgW != nil {
+	if imgW != nil && centerHeight > 0 {
 		imgW = resize.Resize(uint(padLeft), uint(height-padTop-padBottom),
 			imgW, resize.Lanczos3)
 		ctx.DrawImage(imgW, x, y+padTop)
@@ -351,7 +418,7 @@ func (n *Node) drawStyleBox(ctx *gg.Context, ec *EvalContext, name string) {
 	}
 
 	// c
-	if imgC != nil {
+	if imgC != nil && centerWidth > 0 && centerHeight > 0 {
 		imgC = resize.Resize(uint(width-padLeft-padRight),
 			uint(height-padTop-padBottom),
 			imgC, resize.Lanczos3)
@@ -367,7 +434,7 @@ func (n *Node) drawStyleBox(ctx *gg.Context, ec *EvalContext, name string) {
 	}
 
 	// e
-	if imgE != nil {
+	if imgE != nil && centerHeight > 0 {
 		imgE = resize.Resize(uint(padRight), uint(height-padTop-padBottom),
 			imgE, resize.Lanczos3)
 		ctx.DrawImage(imgE, x+width-padRight, y+padTop)
@@ -393,7 +460,7 @@ func (n *Node) drawStyleBox(ctx *gg.Context, ec *EvalContext, name string) {
 	}
 
 	// s
-	if imgS != nil {
+	if imgS != nil && centerWidth > 0 {
 		imgS = resize.Resize(uint(width-padLeft-padRight),
 			uint(padBottom),
 			imgS, resize.Lanczos3)
@@ -441,6 +508,14 @@ func (n *Node) drawText1(ctx *gg.Context, ec *EvalContext, str string, color col
 	ctx.DrawStringWrapped(str, x, y, 0, 0, width, 1, align)
 }
 
+// drawTextBaseline 在基线 y 处绘制文本，坐标含义与 grub_font_draw_string 一致
+func drawTextBaseline(ctx *gg.Context, str string, x, y float64, color color.Color, fontFace *font.Face) {
+	ctx.SetColor(color)
+	ctx.SetFontFace(fontFace)
+	log.Printf("drawTextBaseline str: %q, x: %g, y: %g\n", str, x, y)
+	ctx.DrawStringAnchored(str, x, y-float64(fontFace.Ascent), 0, 1)
+}
+
 func (n *Node) DrawTo(ctx *gg.Context, ec *EvalContext) {
 	if optDrawOutline {
 		x := n.getLeft().Eval(ec)
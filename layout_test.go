@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+
+	tt "github.com/electricface/grub-theme-viewer/themetxt"
+)
+
+// TestGetLengthExpr 检查 getLengthExpr 的结果和 tt.Length 的 GetConvertFunc 一致，
+// want 是父节点大小为 1000 时的值
+func TestGetLengthExpr(t *testing.T) {
+	tests := []struct {
+		str  string
+		want float64
+	}{
+		{"50", 50},
+		{"-10", -10},
+		{"50%", 500},
+		{"50%-20", 480},
+		{"50%+-5", 495},
+		{"10+50%-5+10%", 605},
+		{"0x10", 16},
+		{"010", 8},
+		{"--5", 5},
+	}
+	parents := []float64{0, 1, 333, 768, 1000, 1366}
+
+	ec := newEvalContent()
+	for _, test := range tests {
+		l, err := tt.ParseLength(test.str)
+		if err != nil {
+			t.Errorf("%q: %v", test.str, err)
+			continue
+		}
+		if got := l.GetConvertFunc()(1000); got != test.want {
+			t.Errorf("%q: expected %v with parent 1000, got %v", test.str, test.want, got)
+		}
+		for _, v := range parents {
+			want := l.GetConvertFunc()(v)
+			got := getLengthExpr(l, AbsNum(v)).Eval(ec)
+			if got != want {
+				t.Errorf("%q: parent %v: expected %v, got %v", test.str, v, want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	tt "github.com/electricface/grub-theme-viewer/themetxt"
+)
+
+// lint 子命令检查出的问题的种类，属性相关的问题使用 Validate 的种类
+const (
+	lintMissingFile = "missing-file"
+	lintMissingFont = "missing-font"
+	lintUnknownFont = "unknown-font"
+	lintMissingIcon = "missing-icon"
+	lintOffScreen   = "off-screen"
+	lintOverlap     = "overlap"
+)
+
+// lintProblem 是 lint 检查出的一个问题，Line 和 Col 从 1 开始，
+// 和整个主题有关的问题 Line 和 Col 为 0。
+type lintProblem struct {
+	Kind       string `json:"kind"`
+	Filename   string `json:"file"`
+	Line       int    `json:"line"`
+	Col        int    `json:"col"`
+	Component  string `json:"component,omitempty"`
+	Prop       string `json:"property,omitempty"`
+	Resolution string `json:"resolution,omitempty"`
+	Msg        string `json:"message"`
+}
+
+func (p *lintProblem) String() string {
+	where := p.Component
+	if where == "" {
+		where = "global"
+	}
+	msg := p.Msg
+	if p.Resolution != "" {
+		msg += " at " + p.Resolution
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s [%s]", p.Filename, p.Line, p.Col, where, msg, p.Kind)
+}
+
+type resolution struct {
+	width, height int
+}
+
+func (r resolution) String() string {
+	return fmt.Sprintf("%dx%d", r.width, r.height)
+}
+
+// parseResolutions 解析逗号分隔的分辨率列表，比如 "1024x768,1920x1080"
+func parseResolutions(str string) ([]resolution, error) {
+	var result []resolution
+	for _, item := range strings.Split(str, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.Split(item, "x")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid resolution %q, expect WIDTHxHEIGHT", item)
+		}
+		width, err1 := strconv.Atoi(parts[0])
+		height, err2 := strconv.Atoi(parts[1])
+		if err1 != nil || err2 != nil || width <= 0 || height <= 0 {
+			return nil, fmt.Errorf("invalid resolution %q, expect WIDTHxHEIGHT", item)
+		}
+		result = append(result, resolution{width: width, height: height})
+	}
+	return result, nil
+}
+
+// linter 检查主题，记录检查出的问题
+type linter struct {
+	theme    *tt.Theme
+	problems []*lintProblem
+}
+
+// add 添加一个问题，node 为 nil 时问题没有位置
+func (l *linter) add(kind string, node tt.Node, comp *tt.Component, prop string,
+	format string, args ...interface{}) *lintProblem {
+	p := &lintProblem{
+		Kind:     kind,
+		Filename: l.theme.Filename(),
+		Prop:     prop,
+		Msg:      fmt.Sprintf(format, args...),
+	}
+	if comp != nil {
+		p.Component = describeComp(comp)
+	}
+	if node != nil {
+		p.Line, p.Col = l.theme.Position(node)
+	}
+	l.problems = append(l.problems, p)
+	return p
+}
+
+// describeComp 返回组件的类型，有 id 时加上 id
+func describeComp(comp *tt.Component) string {
+	id, _ := comp.GetPropString("id")
+	if id == "" {
+		return comp.Type
+	}
+	return fmt.Sprintf("%s %q", comp.Type, id)
+}
+
+// walkComps 按源文件中的顺序遍历所有组件，包括子组件
+func walkComps(comps []*tt.Component, fn func(comp *tt.Component)) {
+	for _, comp := range comps {
+		fn(comp)
+		walkComps(comp.Children, fn)
+	}
+}
+
+func (l *linter) checkValidate() {
+	for _, diag := range l.theme.Validate() {
+		l.problems = append(l.problems, &lintProblem{
+			Kind:      diag.Kind.String(),
+			Filename:  diag.Filename,
+			Line:      diag.Line,
+			Col:       diag.Col,
+			Component: diag.Component,
+			Prop:      diag.Prop,
+			Msg:       diag.Msg,
+		})
+	}
+}
+
+// 引用图片文件的属性
+var imagePropNames = map[string][]string{
+	"":                               {"desktop-image"},
+	tt.ComponentTypeImage:            {"file"},
+	tt.ComponentTypeCircularProgress: {"center_bitmap", "tick_bitmap"},
+}
+
+// 引用 styled box 的属性，属性值中的 * 替换为 nw、n、ne 等得到 9 个图片文件
+var styleBoxPropNames = map[string][]string{
+	"":                          {"terminal-box"},
+	tt.ComponentTypeBootMenu:    {"menu_pixmap_style", "item_pixmap_style", "selected_item_pixmap_style", "scrollbar_frame", "scrollbar_thumb"},
+	tt.ComponentTypeProgressBar: {"bar_style", "highlight_style"},
+}
+
+func fileExists(name string) bool {
+	_, err := os.Stat(getResourceFile(name))
+	return err == nil
+}
+
+// checkFileProps 检查属性引用的图片文件是否存在
+func (l *linter) checkFileProps(comp *tt.Component, compType string,
+	findProp func(name string) *tt.Property) {
+	for _, name := range imagePropNames[compType] {
+		prop := findProp(name)
+		if prop == nil || prop.GrubString() == "" {
+			continue
+		}
+		file := prop.GrubString()
+		if !fileExists(file) {
+			l.add(lintMissingFile, prop, comp, name, "file %q not found", file)
+		}
+	}
+
+	for _, name := range styleBoxPropNames[compType] {
+		prop := findProp(name)
+		if prop == nil || prop.GrubString() == "" {
+			continue
+		}
+		// grub 可以只提供部分切片，没有一个切片时才是问题
+		pattern := prop.GrubString()
+		found := false
+		for part := styleBoxNW; part <= styleBoxSE; part++ {
+			if fileExists(getPixmapName(pattern, part)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			l.add(lintMissingFile, prop, comp, name, "no slice of styled box %q found", pattern)
+		}
+	}
+}
+
+func (l *linter) checkFiles() {
+	l.checkFileProps(nil, "", l.theme.FindProp)
+	walkComps(l.theme.Components, func(comp *tt.Component) {
+		l.checkFileProps(comp, comp.Type, comp.FindProp)
+	})
+}
+
+// checkFontProps 检查字体属性使用的字体是否由主题目录中的 .pf2 文件提供
+func (l *linter) checkFontProps(comp *tt.Component, compType string, props []*tt.Property) {
+	for _, prop := range props {
+		spec := tt.GetPropSpec(compType, prop.Name())
+		if spec == nil || spec.Type != tt.PropTypeFont {
+			continue
+		}
+		name := prop.GrubString()
+		if name == "" || name == "inherit" {
+			continue
+		}
+		if !hasFontFace(name) {
+			l.add(lintUnknownFont, prop, comp, prop.Name(),
+				"font %q is not provided by any .pf2 file in %s, grub will use %q",
+				name, globalThemeDir, getFallbackFont().Name)
+		}
+	}
+}
+
+// hasFontFace 判断是否有名称完全相同的字体，grub_font_get 只按名称查找字体，
+// 找不到时使用字体列表中的第一个，也就是最后加载的字体
+func hasFontFace(name string) bool {
+	for _, face := range allFontFaces {
+		if face.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFonts 检查字体，返回是否有可用的字体
+func (l *linter) checkFonts() bool {
+	if len(allFontFaces) == 0 {
+		l.add(lintMissingFont, nil, nil, "",
+			"no .pf2 font found in %s, skip checking fonts and layout", globalThemeDir)
+		return false
+	}
+	l.checkFontProps(nil, "", l.theme.Props)
+	walkComps(l.theme.Components, func(comp *tt.Component) {
+		l.checkFontProps(comp, comp.Type, comp.Props)
+	})
+	return true
+}
+
+// checkIcons 检查菜单项是否有图标，只在有 boot_menu 时检查
+func (l *linter) checkIcons() {
+	var bootMenu *tt.Component
+	walkComps(l.theme.Components, func(comp *tt.Component) {
+		if bootMenu == nil && comp.Type == tt.ComponentTypeBootMenu {
+			bootMenu = comp
+		}
+	})
+	if bootMenu == nil {
+		return
+	}
+
+	var check func(entries []*menuEntry)
+	check = func(entries []*menuEntry) {
+		for _, entry := range entries {
+			if len(entry.classes) > 0 && globalIconManager.getIcon(entry) == "" {
+				l.add(lintMissingIcon, bootMenu, bootMenu, "",
+					"no icon for entry %q, tried icons/<class>.png for classes: %s",
+					entry.title, strings.Join(entry.classes, " "))
+			}
+			check(entry.entries)
+		}
+	}
+	check(globalMenuEntries)
+}
+
+// rect 是节点在屏幕上的位置和大小
+type rect struct {
+	x, y, width, height float64
+}
+
+func (r rect) String() string {
+	return fmt.Sprintf("(%.0f,%.0f %.0fx%.0f)", r.x, r.y, r.width, r.height)
+}
+
+func (r rect) isValid() bool {
+	for _, v := range []float64{r.x, r.y, r.width, r.height} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return r.width > 0 && r.height > 0
+}
+
+func (r rect) intersects(o rect) bool {
+	return r.x < o.x+o.width && o.x < r.x+r.width &&
+		r.y < o.y+o.height && o.y < r.y+r.height
+}
+
+func (r rect) contains(o rect) bool {
+	return r.x <= o.x && r.y <= o.y &&
+		o.x+o.width <= r.x+r.width && o.y+o.height <= r.y+r.height
+}
+
+func getNodeRect(n *Node, ec *EvalContext) rect {
+	return rect{
+		x:      n.getLeft().Eval(ec),
+		y:      n.getTop().Eval(ec),
+		width:  n.getWidth().Eval(ec),
+		height: n.getHeight().Eval(ec),
+	}
+}
+
+// isNodeVisible 判断节点对应的组件是否可见，visible 为 false 的组件不参与检查
+func isNodeVisible(n *Node) bool {
+	if n.comp == nil {
+		return false
+	}
+	visible, ok := n.comp.GetPropBool("visible")
+	return !ok || visible
+}
+
+// checkLayout 在分辨率 res 下计算组件的位置，检查超出屏幕和相互重叠的组件。
+// 一个组件完全包含另一个组件时，通常是有意为之，比如进度条上的标签，不算重叠。
+func (l *linter) checkLayout(res resolution) {
+	ec := newEvalContent()
+	ec.setUnknown("screen-width", float64(res.width))
+	ec.setUnknown("screen-height", float64(res.height))
+	ec.setUnknown("timeout", float64(optTimeout))
+	ec.setUnknown("timeout-left", float64(optTimeoutLeft))
+
+	root := themeToNodeTree(l.theme, res.width, res.height)
+	screen := rect{width: float64(res.width), height: float64(res.height)}
+
+	var check func(parent *Node)
+	check = func(parent *Node) {
+		var nodes []*Node
+		var rects []rect
+		for _, child := range parent.Children {
+			if !isNodeVisible(child) {
+				continue
+			}
+			r := getNodeRect(child, ec)
+			if !r.isValid() {
+				continue
+			}
+			if !screen.contains(r) {
+				msg := "component is partly off-screen"
+				if !screen.intersects(r) {
+					msg = "component is off-screen"
+				}
+				p := l.add(lintOffScreen, child.comp, child.comp, "", "%s %v", msg, r)
+				p.Resolution = res.String()
+			}
+			for i, o := range rects {
+				if r.intersects(o) && !r.contains(o) && !o.contains(r) {
+					p := l.add(lintOverlap, child.comp, child.comp, "",
+						"component %v overlaps %s %v", r, describeComp(nodes[i].comp), o)
+					p.Resolution = res.String()
+				}
+			}
+			nodes = append(nodes, child)
+			rects = append(rects, r)
+			check(child)
+		}
+	}
+	check(root)
+}
+
+// lintTheme 检查主题，返回按位置排序的问题
+func lintTheme(theme *tt.Theme, resolutions []resolution) []*lintProblem {
+	l := &linter{theme: theme}
+	l.checkValidate()
+	l.checkFiles()
+	l.checkIcons()
+	if l.checkFonts() {
+		for _, res := range resolutions {
+			l.checkLayout(res)
+		}
+	}
+	sort.SliceStable(l.problems, func(i, j int) bool {
+		a, b := l.problems[i], l.problems[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Col < b.Col
+	})
+	return l.problems
+}
+
+// runLint 是 lint 子命令，输出检查出的问题，有问题时退出码为 1
+func runLint(theme *tt.Theme) {
+	resolutions := []resolution{{width: optScreenWidth, height: optScreenHeight}}
+	if optResolutions != "" {
+		var err error
+		resolutions, err = parseResolutions(optResolutions)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if optGrubCfg != "" {
+		globalMenuEntries = loadMenuEntries(optGrubCfg)
+	}
+	loadAllFonts()
+
+	problems := lintTheme(theme, resolutions)
+	if optLintJSON {
+		if problems == nil {
+			problems = []*lintProblem{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err := enc.Encode(problems)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+	}
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+}
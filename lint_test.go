@@ -0,0 +1,97 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tt "github.com/electricface/grub-theme-viewer/themetxt"
+)
+
+func TestParseResolutions(t *testing.T) {
+	tests := []struct {
+		str  string
+		want []resolution
+	}{
+		{"1024x768", []resolution{{1024, 768}}},
+		{"1024x768, 1920x1080,", []resolution{{1024, 768}, {1920, 1080}}},
+		{"", nil},
+	}
+	for _, test := range tests {
+		got, err := parseResolutions(test.str)
+		if err != nil {
+			t.Errorf("%q: %v", test.str, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: expected %v, got %v", test.str, test.want, got)
+		}
+	}
+
+	for _, str := range []string{"1024", "1024x", "x768", "1024x768x32", "0x768", "-1x768", "ax b"} {
+		if got, err := parseResolutions(str); err == nil {
+			t.Errorf("%q: expected error, got %v", str, got)
+		}
+	}
+}
+
+func TestCheckLayout(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "ok",
+			src: `+ canvas { left = 0 top = 0 width = 50% height = 100% }
++ canvas { left = 50% top = 0 width = 50% height = 100% }
+`,
+		},
+		{
+			name: "contains",
+			// 完全包含的组件不算重叠
+			src: `+ canvas { left = 0 top = 0 width = 100 height = 100 }
++ canvas { left = 10 top = 10 width = 20 height = 20 }
+`,
+		},
+		{
+			name: "overlap",
+			src: `+ canvas { left = 0 top = 0 width = 100 height = 100 }
++ canvas { left = 50 top = 50 width = 100 height = 100 }
++ canvas { left = 50 top = 50 width = 100 height = 100 visible = false }
+`,
+			want: []string{
+				`theme.txt:3:1: canvas: component (50,50 100x100) overlaps canvas (0,0 100x100) at 640x480 [overlap]`,
+			},
+		},
+		{
+			name: "off-screen",
+			src: `+ canvas { left = 600 top = 0 width = 100 height = 100 }
++ canvas { left = 0 top = 100% width = 100 height = 100 }
+`,
+			want: []string{
+				`theme.txt:2:1: canvas: component is partly off-screen (600,0 100x100) at 640x480 [off-screen]`,
+				`theme.txt:3:1: canvas: component is off-screen (0,480 100x100) at 640x480 [off-screen]`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// 不显示标题，只检查组件
+			theme, err := tt.ParseTheme("theme.txt", []byte("title-text: \"\"\n"+test.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			l := &linter{theme: theme}
+			l.checkLayout(resolution{width: 640, height: 480})
+			var got []string
+			for _, p := range l.problems {
+				got = append(got, p.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"),
+					strings.Join(test.want, "\n"))
+			}
+		})
+	}
+}
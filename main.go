@@ -27,20 +27,67 @@ var optOutput string
 var optScreenWidth int
 var optScreenHeight int
 
+var optTimeout int
+var optTimeoutLeft int
+
+var optScreen string
+var optTranscript string
+var optEditEntry int
+var optGrubCfg string
+var optSelected int
+var optEntries int
+var optNav string
+var optCountdown bool
+var optKeys string
+var optLang string
+var optLocaleDir string
+var optResolutions string
+var optLintJSON bool
+
 var globalThemeDir string
 
 func init() {
 	flag.StringVar(&optThemeFile, "theme", "", "theme file")
 	flag.StringVar(&optThemeDir, "theme-dir", "", "theme dir")
 	flag.BoolVar(&optDraw, "draw", false, "draw out.png")
-	flag.StringVar(&optOutput, "out", "./out.png", "output image file")
+	flag.StringVar(&optOutput, "out", "", "output image file, ./out.png by default, ./out.gif with -countdown")
 	flag.BoolVar(&optDump, "dump", false, "dump theme")
+	flag.BoolVar(&tt.Verbose, "parse-debug", false, "log comments, properties and components while parsing theme")
 	flag.BoolVar(&optDrawOutline, "outline", false, "draw outline")
 
 	flag.IntVar(&optScreenWidth, "width", 1366, "screen width (px)")
 	flag.IntVar(&optScreenHeight, "height", 768, "screen height (px)")
+
+	flag.IntVar(&optTimeout, "timeout", 10, "timeout (seconds)")
+	flag.IntVar(&optTimeoutLeft, "timeout-left", 5, "seconds left before timeout")
+
+	flag.BoolVar(&optCountdown, "countdown", false, "draw the timeout countdown as an animated GIF")
+
+	flag.StringVar(&optKeys, "keys", "",
+		`key script like "down down enter", keys: up down home end page-up page-down enter esc`)
+
+	flag.StringVar(&optLang, "lang", "", "language of grub messages, like zh_CN")
+	flag.StringVar(&optLocaleDir, "locale-dir", "/boot/grub/locale", "dir of grub .mo files")
+
+	flag.StringVar(&optScreen, "screen", screenMenu, "screen to draw: menu, console, editor")
+	flag.StringVar(&optTranscript, "transcript", "", "text file shown in the console screen")
+	flag.StringVar(&optGrubCfg, "grub-cfg", "", "read menu entries from grub.cfg")
+	flag.IntVar(&optSelected, "selected", 0, "index of the selected entry")
+	flag.IntVar(&optEntries, "entries", 0, "number of entries in the menu, 0 means all entries of the menu source")
+	flag.StringVar(&optNav, "nav", "", `navigation path like "1>2", enter submenus and select the last entry`)
+	flag.IntVar(&optEditEntry, "edit-entry", 0, "index of the entry shown in the editor screen")
+
+	flag.StringVar(&optResolutions, "resolutions", "",
+		`lint: resolutions to check the layout at, like "1024x768,1920x1080", default is -width x -height`)
+	flag.BoolVar(&optLintJSON, "json", false, "lint: print problems as JSON")
 }
 
+const (
+	screenMenu    = "menu"
+	screenConsole = "console"
+	screenEditor  = "editor"
+)
+
 func testMain() {
 	ec := newEvalContent()
 	ec.setUnknown("screen-width", 500)
@@ -75,22 +122,69 @@ func main() {
 	log.SetFlags(log.Lshortfile)
 	flag.Parse()
 
+	// 子命令，比如 grub-theme-viewer lint -theme theme.txt
+	var cmd string
+	if flag.NArg() > 0 {
+		cmd = flag.Arg(0)
+		switch cmd {
+		case "lint":
+			flag.CommandLine.Parse(flag.Args()[1:])
+		case "fmt":
+			runFmt(flag.Args()[1:])
+			return
+		default:
+			log.Fatalf("unknown command %q", cmd)
+		}
+	}
+
 	if optThemeDir == "" {
 		globalThemeDir = filepath.Dir(optThemeFile)
 	} else {
 		globalThemeDir = optThemeDir
 	}
 
+	if optOutput == "" {
+		optOutput = "./out.png"
+		if optCountdown {
+			optOutput = "./out.gif"
+		}
+	} else if optCountdown && strings.ToLower(filepath.Ext(optOutput)) != ".gif" {
+		log.Fatalf("countdown is saved as GIF, output file %q should end with .gif", optOutput)
+	}
+
 	theme, err := tt.ParseThemeFile(optThemeFile)
 	if err != nil {
 		log.Fatal(err)
 	}
+	if cmd == "lint" {
+		runLint(theme)
+		return
+	}
+	for _, diag := range theme.Validate() {
+		log.Println("WARN:", diag)
+	}
 
 	if optDump {
 		theme.Dump()
 	}
 
 	if optDraw {
+		if optGrubCfg != "" {
+			globalMenuEntries = loadMenuEntries(optGrubCfg)
+		}
+		if optEntries > 0 {
+			globalMenuEntries = resizeMenuEntries(globalMenuEntries, optEntries)
+		}
+		if optNav != "" {
+			globalSubmenus, optSelected, err = parseNavPath(globalMenuEntries, optNav)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+		globalSelected = optSelected
+		if optLang != "" {
+			loadCatalog(optLang)
+		}
 		loadAllFonts()
 		// draw
 		draw(theme)
@@ -103,16 +197,41 @@ func draw(theme *tt.Theme) {
 	ec := newEvalContent()
 	ec.setUnknown("screen-width", float64(optScreenWidth))
 	ec.setUnknown("screen-height", float64(optScreenHeight))
+	ec.setUnknown("timeout", float64(optTimeout))
+	ec.setUnknown("timeout-left", float64(optTimeoutLeft))
 
-	root := themeToNodeTree(theme, optScreenWidth, optScreenHeight)
-	ctx := gg.NewContext(optScreenWidth, optScreenHeight)
-	// 画背景
-	root.draw = func(n *Node, ctx *gg.Context, ec *EvalContext) {
-		n.drawImage(ctx, ec, "background.png")
+	if optKeys != "" {
+		if optScreen != screenMenu {
+			log.Fatal("key script only works on the menu screen")
+		}
+		drawKeyScript(theme, ec)
+		globalIconManager.reportMissing()
+		return
 	}
 
-	root.DrawTo(ctx, ec)
-	ctx.SavePNG(optOutput)
+	var root *Node
+	switch optScreen {
+	case screenMenu:
+		root = themeToNodeTree(theme, optScreenWidth, optScreenHeight)
+	case screenConsole:
+		root = consoleToNodeTree(theme)
+	case screenEditor:
+		root = editorToNodeTree(theme)
+	default:
+		log.Fatalf("unknown screen %q", optScreen)
+	}
+	// 画背景
+	desktop := newDesktop(theme)
+	root.draw = desktop.draw
+
+	if optCountdown {
+		drawCountdown(root, ec)
+	} else {
+		ctx := gg.NewContext(optScreenWidth, optScreenHeight)
+		root.DrawTo(ctx, ec)
+		ctx.SavePNG(optOutput)
+	}
+	globalIconManager.reportMissing()
 }
 
 func getResourceFile(name string) string {
@@ -122,18 +241,57 @@ func getResourceFile(name string) string {
 
 func themeToNodeTree(theme *tt.Theme, w, h int) *Node {
 	root := &Node{}
+	globalBootMenus = nil
+	title := titleToNode(theme, root)
+	if title != nil {
+		root.addChild(title)
+	}
+
 	for _, comp := range theme.Components {
-		if comp.Type == "boot_menu" {
-			log.Println("add child boot_menu")
-			root.addChild(compBootMenuToNode(comp, root))
-		} else if comp.Type == "label" {
-			log.Println("add child label")
-			root.addChild(compLabelToNode(comp, root))
+		node := compToNode(comp, root)
+		if node != nil {
+			root.addChild(node)
 		}
 	}
+
+	// 菜单的高度要在节点树建好之后才能计算
+	ec := newEvalContent()
+	ec.setUnknown("screen-width", float64(w))
+	ec.setUnknown("screen-height", float64(h))
+	for _, bm := range globalBootMenus {
+		bm.layoutItems(ec)
+	}
 	return root
 }
 
+func compToNode(comp *tt.Component, parent *Node) *Node {
+	log.Printf("add child %s\n", comp.Type)
+	var node *Node
+	switch comp.Type {
+	case tt.ComponentTypeBootMenu:
+		node = compBootMenuToNode(comp, parent)
+	case tt.ComponentTypeLabel:
+		node = compLabelToNode(comp, parent)
+	case tt.ComponentTypeProgressBar:
+		node = compProgressBarToNode(comp, parent)
+	case tt.ComponentTypeCircularProgress:
+		node = compCircularProgressToNode(comp, parent)
+	case tt.ComponentTypeImage:
+		node = compImageToNode(comp, parent)
+	case tt.ComponentTypeHBox, tt.ComponentTypeVBox:
+		node = compBoxToNode(comp, parent)
+	case tt.ComponentTypeCanvas:
+		node = compCanvasToNode(comp, parent)
+	default:
+		log.Printf("WARN: unsupported component type %q\n", comp.Type)
+		return nil
+	}
+	if node != nil {
+		node.comp = comp
+	}
+	return node
+}
+
 type CompCommon struct {
 	left   tt.Length
 	top    tt.Length
@@ -147,12 +305,25 @@ type CompCommon struct {
 func getFont(name string) *font.Face {
 	face := getFontAux(name)
 	if face == nil {
-		panic("not found font face for " + name)
+		face = getFallbackFont()
+		if face == nil {
+			panic("not found font face for " + name)
+		}
+		log.Printf("WARN: not found font face for %q, fallback to %q\n", name, face.Name)
 	}
 	log.Printf("getFont %q -> %q\n", name, face.Name)
 	return face
 }
 
+// getFallbackFont 返回找不到字体时使用的字体。grub 的 register_font 把字体加到
+// 列表的开头，grub_font_get 找不到时使用列表中的第一个字体，即最后加载的字体。
+func getFallbackFont() *font.Face {
+	if len(allFontFaces) == 0 {
+		return nil
+	}
+	return allFontFaces[len(allFontFaces)-1]
+}
+
 func getFontAux(name string) *font.Face {
 	for _, face := range allFontFaces {
 		if face.Name == name {
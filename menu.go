@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/electricface/grub-theme-viewer/grubcfg"
+)
+
+// menuEntry 菜单项，对应 grub.cfg 中的 menuentry 或 submenu
+type menuEntry struct {
+	title   string
+	classes []string
+	id      string
+	// 花括号中的脚本，按行分开
+	script []string
+
+	submenu bool
+	entries []*menuEntry
+}
+
+// defaultMenuEntries 没有指定菜单来源时使用的示例菜单
+var defaultMenuEntries = []*menuEntry{
+	{
+		title:   "Deepin GNU/Linux",
+		classes: []string{"deepin", "gnu-linux", "gnu", "os"},
+		script: []string{
+			"\tload_video",
+			"\tinsmod gzio",
+			"\tif [ x$grub_platform = xxen ]; then insmod xzio; insmod lzopio; fi",
+			"\tinsmod part_msdos",
+			"\tinsmod ext2",
+			"\tset root='hd0,msdos1'",
+			"\tsearch --no-floppy --fs-uuid --set=root 1e2ab8f5-6d4c-4b1e-9f0a-3c9d2e7a8b10",
+			"\techo\t'Loading Linux 4.15.0-30deepin-generic ...'",
+			"\tlinux\t/boot/vmlinuz-4.15.0-30deepin-generic root=UUID=1e2ab8f5-6d4c-4b1e-9f0a-3c9d2e7a8b10 ro  splash quiet",
+			"\techo\t'Loading initial ramdisk ...'",
+			"\tinitrd\t/boot/initrd.img-4.15.0-30deepin-generic",
+		},
+	},
+	{
+		title:   "Advanced options for Deepin GNU/Linux",
+		submenu: true,
+		entries: []*menuEntry{
+			{
+				title:   "Deepin GNU/Linux, with Linux 4.15.0-30deepin-generic",
+				classes: []string{"deepin", "gnu-linux", "gnu", "os"},
+				script: []string{
+					"\t\tload_video",
+					"\t\tinsmod ext2",
+					"\t\tset root='hd0,msdos1'",
+					"\t\tlinux\t/boot/vmlinuz-4.15.0-30deepin-generic root=UUID=1e2ab8f5-6d4c-4b1e-9f0a-3c9d2e7a8b10 ro  splash quiet",
+					"\t\tinitrd\t/boot/initrd.img-4.15.0-30deepin-generic",
+				},
+			},
+			{
+				title:   "Deepin GNU/Linux, with Linux 4.15.0-30deepin-generic (recovery mode)",
+				classes: []string{"deepin", "gnu-linux", "gnu", "os"},
+				script: []string{
+					"\t\tload_video",
+					"\t\tinsmod ext2",
+					"\t\tset root='hd0,msdos1'",
+					"\t\tlinux\t/boot/vmlinuz-4.15.0-30deepin-generic root=UUID=1e2ab8f5-6d4c-4b1e-9f0a-3c9d2e7a8b10 ro single",
+					"\t\tinitrd\t/boot/initrd.img-4.15.0-30deepin-generic",
+				},
+			},
+		},
+	},
+	{
+		title:   "Window XP",
+		classes: []string{"windows", "os"},
+		script: []string{
+			"\tinsmod part_msdos",
+			"\tinsmod ntfs",
+			"\tset root='hd0,msdos2'",
+			"\tparttool ${root} hidden-",
+			"\tdrivemap -s (hd0) ${root}",
+			"\tchainloader +1",
+		},
+	},
+	{
+		title: "System setup",
+		script: []string{
+			"\tfwsetup",
+		},
+	},
+}
+
+// globalMenuEntries 是当前的菜单来源
+var globalMenuEntries = defaultMenuEntries
+
+// loadMenuEntries 从 grub.cfg 读取菜单
+func loadMenuEntries(filename string) []*menuEntry {
+	entries, err := grubcfg.ParseFile(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(entries) == 0 {
+		log.Printf("WARN: no menu entries in %s\n", filename)
+	}
+	return convertMenuEntries(entries)
+}
+
+func convertMenuEntries(entries []*grubcfg.Entry) []*menuEntry {
+	result := make([]*menuEntry, len(entries))
+	for i, entry := range entries {
+		result[i] = &menuEntry{
+			title:   entry.Title,
+			classes: entry.Classes,
+			id:      entry.ID,
+			script:  entry.Script,
+			submenu: entry.Submenu,
+			entries: convertMenuEntries(entry.Entries),
+		}
+	}
+	return result
+}
+
+// resizeMenuEntries 把菜单项的数量调整为 n，不够时重复已有的菜单项，
+// 用于预览很长的菜单。
+func resizeMenuEntries(entries []*menuEntry, n int) []*menuEntry {
+	if n <= len(entries) || len(entries) == 0 {
+		if n < len(entries) {
+			return entries[:n]
+		}
+		return entries
+	}
+
+	result := make([]*menuEntry, n)
+	copy(result, entries)
+	for i := len(entries); i < n; i++ {
+		entry := *entries[i%len(entries)]
+		entry.title = fmt.Sprintf("%s #%d", entry.title, i+1)
+		result[i] = &entry
+	}
+	return result
+}
+
+// 进入的子菜单，从最外层开始
+var globalSubmenus []*menuEntry
+
+// 当前菜单中选中的菜单项
+var globalSelected int
+
+// getCurrentMenuEntries 返回当前菜单中的菜单项，进入子菜单后是子菜单的菜单项
+func getCurrentMenuEntries() []*menuEntry {
+	if len(globalSubmenus) == 0 {
+		return globalMenuEntries
+	}
+	return globalSubmenus[len(globalSubmenus)-1].entries
+}
+
+// findMenuEntry 参考 grub 的 get_entry_number，name 是导航路径中的一项。
+// 和 grub_strtoul 一样能读出开头的数字时按序号查找，否则查找标题或 id 完全相同的菜单项。
+func findMenuEntry(entries []*menuEntry, name string) (int, bool) {
+	if idx, ok := parseEntryNumber(name); ok {
+		return idx, idx < len(entries)
+	}
+	for i, entry := range entries {
+		if entry.title == name || (entry.id != "" && entry.id == name) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// parseEntryNumber 参考 grub_strtoul(str, 0, 0)，跳过开头的空白，0x 开头是十六进制，
+// 0 开头是八进制，读到不是数字的字符为止，一个数字都没有时返回 false。
+func parseEntryNumber(str string) (int, bool) {
+	str = strings.TrimLeft(str, " \t\n\r")
+	base := 10
+	if strings.HasPrefix(str, "0x") {
+		base = 16
+		str = str[2:]
+	} else if len(str) > 1 && str[0] == '0' && str[1] >= '0' && str[1] <= '7' {
+		base = 8
+	}
+
+	n := 0
+	found := false
+	for _, c := range str {
+		var digit int
+		switch {
+		case c >= '0' && c <= '9':
+			digit = int(c - '0')
+		case c >= 'a' && c <= 'z':
+			digit = int(c-'a') + 10
+		case c >= 'A' && c <= 'Z':
+			digit = int(c-'A') + 10
+		default:
+			digit = base
+		}
+		if digit >= base {
+			break
+		}
+		found = true
+		n = n*base + digit
+		if n > math.MaxInt32 {
+			// 超出范围时 grub 找不到菜单项
+			return math.MaxInt32, true
+		}
+	}
+	return n, found
+}
+
+// splitNavPath 参考 grub 的 menuentry_eq，用不在 >> 中的 > 分开导航路径，>> 表示一个 >
+func splitNavPath(path string) []string {
+	var names []string
+	var name []byte
+	for i := 0; i < len(path); i++ {
+		if path[i] == '>' {
+			if i+1 < len(path) && path[i+1] == '>' {
+				name = append(name, '>')
+				i++
+				continue
+			}
+			names = append(names, string(name))
+			name = nil
+			continue
+		}
+		name = append(name, path[i])
+	}
+	return append(names, string(name))
+}
+
+// parseNavPath 解析导航路径，格式和 grub 的 default 变量一样，
+// 最后一项是选中的菜单项，之前的都是要进入的子菜单。
+func parseNavPath(entries []*menuEntry, path string) (submenus []*menuEntry, selected int, err error) {
+	names := splitNavPath(path)
+	for i, name := range names {
+		idx, ok := findMenuEntry(entries, name)
+		if !ok {
+			return nil, 0, fmt.Errorf("nav path %q: entry %q not found", path, name)
+		}
+		if i == len(names)-1 {
+			return submenus, idx, nil
+		}
+
+		entry := entries[idx]
+		if !entry.submenu {
+			return nil, 0, fmt.Errorf("nav path %q: %q is not a submenu", path, entry.title)
+		}
+		submenus = append(submenus, entry)
+		entries = entry.entries
+	}
+	return submenus, 0, nil
+}
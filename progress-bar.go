@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+
+	tt "github.com/electricface/grub-theme-viewer/themetxt"
+
+	"github.com/fogleman/gg"
+)
+
+// 倒计时的状态，由 EvalContext 提供
+var timeoutExpr = &Unknown{name: "timeout"}
+var timeoutLeftExpr = &Unknown{name: "timeout-left"}
+
+// 为 0 时倒计时已经取消
+var timeoutVisibleExpr = &Unknown{name: "timeout-visible"}
+
+// isHiddenTimeout 按键后 grub 会取消倒计时，隐藏 id 为 __timeout__ 的组件
+func (cc *CompCommon) isHiddenTimeout(ec *EvalContext) bool {
+	return cc.id == "__timeout__" && timeoutVisibleExpr.Eval(ec) == 0
+}
+
+// expandTextTemplate 展开 grub 中 progress_bar 和 label 的 text 模板，并翻译
+func expandTextTemplate(text string) string {
+	switch text {
+	case "@KEYMAP_LONG@":
+		return gettextGrub("Press enter to boot the selected OS, " +
+			"`e' to edit the commands before booting " +
+			"or `c' for a command-line. ESC to return previous menu.")
+	case "@KEYMAP_MIDDLE@":
+		return gettextGrub("Press enter to boot the selected OS, " +
+			"`e' to edit the commands before booting " +
+			"or `c' for a command-line.")
+	case "@KEYMAP_SHORT@":
+		return gettextGrub("enter: boot, `e': options, `c': cmd-line")
+	case "@TIMEOUT_NOTIFICATION_LONG@":
+		return gettextGrub("The highlighted entry will be executed automatically in %ds.")
+	case "@TIMEOUT_NOTIFICATION_MIDDLE@":
+		return gettextGrub("%ds remaining.")
+	case "@TIMEOUT_NOTIFICATION_SHORT@":
+		return gettextGrub("%ds")
+	}
+	return text
+}
+
+type ProgressBar struct {
+	CompCommon
+
+	text             string
+	font             string
+	textColor        string
+	borderColor      string
+	bgColor          string
+	fgColor          string
+	barStyle         string
+	highlightStyle   string
+	highlightOverlay bool
+}
+
+func newProgressBar(comp *tt.Component) *ProgressBar {
+	pb := &ProgressBar{}
+	pb.node = &Node{}
+	pb.fillCommonOptions(comp)
+
+	pb.text, _ = comp.GetPropString("text")
+	pb.text = expandTextTemplate(pb.text)
+
+	pb.font, _ = comp.GetPropString("font")
+
+	pb.textColor, _ = comp.GetPropString("text_color")
+
+	pb.borderColor, _ = comp.GetPropString("border_color")
+
+	pb.bgColor, _ = comp.GetPropString("bg_color")
+
+	pb.fgColor, _ = comp.GetPropString("fg_color")
+
+	pb.barStyle, _ = comp.GetPropString("bar_style")
+	pb.highlightStyle, _ = comp.GetPropString("highlight_style")
+	pb.highlightOverlay, _ = comp.GetPropBool("highlight_overlay")
+	return pb
+}
+
+// getProgress 返回倒计时已经走过的比例，范围是 [0, 1]
+func getProgress(ec *EvalContext) float64 {
+	total := timeoutExpr.Eval(ec)
+	left := timeoutLeftExpr.Eval(ec)
+	if math.IsNaN(total) || math.IsNaN(left) || total <= 0 {
+		return 0
+	}
+	progress := (total - left) / total
+	if progress < 0 {
+		return 0
+	}
+	if progress > 1 {
+		return 1
+	}
+	return progress
+}
+
+func (pb *ProgressBar) getText(ec *EvalContext) string {
+	if strings.Contains(pb.text, "%d") {
+		return fmt.Sprintf(pb.text, int(timeoutLeftExpr.Eval(ec)))
+	}
+	return pb.text
+}
+
+// drawFilledRectBar 参考 grub 的 draw_filled_rect_bar
+func (pb *ProgressBar) drawFilledRectBar(ctx *gg.Context, x, y, width, height int,
+	progress float64) {
+	fillRect := func(c color.Color, x, y, w, h int) {
+		if w <= 0 || h <= 0 {
+			return
+		}
+		ctx.SetColor(c)
+		ctx.DrawRectangle(float64(x), float64(y), float64(w), float64(h))
+		ctx.Fill()
+	}
+
+	// frame
+	fx := x + 1
+	fy := y + 1
+	fw := width - 2
+	fh := height - 2
+
+	borderColor := parseColor(pb.borderColor)
+	fillRect(borderColor, x, y, width, 1)
+	fillRect(borderColor, x, y+height-1, width, 1)
+	fillRect(borderColor, x, fy, 1, fh)
+	fillRect(borderColor, x+width-1, fy, 1, fh)
+
+	barWidth := int(float64(fw) * progress)
+	fillRect(parseColor(pb.fgColor), fx, fy, barWidth, fh)
+	fillRect(parseColor(pb.bgColor), fx+barWidth, fy, fw-barWidth, fh)
+}
+
+// drawPixmapBar 参考 grub 的 draw_pixmap_bar
+func (pb *ProgressBar) drawPixmapBar(ctx *gg.Context, x, y, width, height int,
+	progress float64) {
+	barPadLeft, barPadRight, barPadTop, barPadBottom := getPads(pb.barStyle)
+	hlPadLeft, hlPadRight, hlPadTop, hlPadBottom := getPads(pb.highlightStyle)
+
+	barHPad := barPadLeft + barPadRight
+	barVPad := barPadTop + barPadBottom
+	hlHPad := hlPadLeft + hlPadRight
+	hlVPad := hlPadTop + hlPadBottom
+
+	trackLen := width - barHPad
+	trackHeight := height - barVPad
+	hlHeight := trackHeight
+	hlX := barPadLeft
+	hlY := barPadTop
+
+	drawStyleBoxRect(ctx, pb.barStyle, x, y, width, height)
+
+	if pb.highlightOverlay {
+		trackLen += hlHPad
+		hlX -= hlPadLeft
+		hlY -= hlPadTop
+	} else {
+		hlHeight -= hlVPad
+	}
+
+	barWidth := int(float64(trackLen) * progress)
+	if barWidth >= hlHPad {
+		drawStyleBoxRect(ctx, pb.highlightStyle, x+hlX, y+hlY,
+			barWidth, hlHeight+hlVPad)
+	}
+}
+
+func (pb *ProgressBar) drawText(ctx *gg.Context, ec *EvalContext, x, y, width, height int) {
+	if pb.text == "" {
+		return
+	}
+
+	text := pb.getText(ec)
+	fontFace := getFont(pb.font)
+	ctx.SetFontFace(fontFace)
+	textWidth, _ := ctx.MeasureString(text)
+
+	// 文字居中
+	textX := float64(x) + (float64(width)-textWidth)/2
+	textY := float64(y + (height-fontFace.Descent)/2 + fontFace.Ascent/2)
+	drawTextBaseline(ctx, text, textX, textY, parseColor(pb.textColor), fontFace)
+}
+
+// setPrefSize 参考 grub 的 progress_bar_get_minimal_size
+func (pb *ProgressBar) setPrefSize() {
+	var width, height int
+	if pb.text != "" {
+		fontFace := getFont(pb.font)
+		width = fontFace.MeasureString(pb.text)
+		height = fontFace.Height()
+	}
+
+	if hasStyleBox(pb.barStyle) && hasStyleBox(pb.highlightStyle) {
+		barPadLeft, barPadRight, barPadTop, barPadBottom := getPads(pb.barStyle)
+		hlPadLeft, hlPadRight, hlPadTop, hlPadBottom := getPads(pb.highlightStyle)
+		width += barPadLeft + barPadRight + hlPadLeft + hlPadRight
+		height += barPadTop + barPadBottom + hlPadTop + hlPadBottom
+	} else {
+		width += 2
+		height += 2
+	}
+
+	pb.node.prefWidth = AbsNum(width)
+	pb.node.prefHeight = AbsNum(height)
+}
+
+func compProgressBarToNode(comp *tt.Component, parent *Node) *Node {
+	pb := newProgressBar(comp)
+	pb.setPrefSize()
+	pb.node.draw = func(n *Node, ctx *gg.Context, ec *EvalContext) {
+		if pb.isHiddenTimeout(ec) {
+			return
+		}
+		x := int(n.getLeft().Eval(ec))
+		y := int(n.getTop().Eval(ec))
+		width := int(n.getWidth().Eval(ec))
+		height := int(n.getHeight().Eval(ec))
+		progress := getProgress(ec)
+
+		if hasStyleBox(pb.barStyle) && hasStyleBox(pb.highlightStyle) {
+			pb.drawPixmapBar(ctx, x, y, width, height, progress)
+		} else {
+			pb.drawFilledRectBar(ctx, x, y, width, height, progress)
+		}
+		pb.drawText(ctx, ec, x, y, width, height)
+	}
+	return pb.node
+}
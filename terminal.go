@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"log"
+	"strings"
+
+	"github.com/electricface/grub-theme-viewer/font"
+	tt "github.com/electricface/grub-theme-viewer/themetxt"
+
+	"github.com/fogleman/gg"
+)
+
+const grubVersion = "2.02"
+
+// gfxterm 默认的前景色 light-gray
+var termNormalColor = color.NRGBA{R: 0xa8, G: 0xa8, B: 0xa8, A: 255}
+
+// Terminal 对应全局属性 terminal-box, terminal-font, terminal-left,
+// terminal-top, terminal-width, terminal-height 和 terminal-border，
+// 模拟 gfxterm 的终端窗口。
+type Terminal struct {
+	box    string
+	font   string
+	left   tt.Length
+	top    tt.Length
+	width  tt.Length
+	height tt.Length
+	border int
+}
+
+func newTerminal(theme *tt.Theme) *Terminal {
+	t := &Terminal{}
+	t.box, _ = theme.GetPropString("terminal-box")
+
+	t.font, _ = theme.GetPropString("terminal-font")
+
+	t.left, _ = theme.GetPropLength("terminal-left")
+
+	t.top, _ = theme.GetPropLength("terminal-top")
+
+	t.width, _ = theme.GetPropLength("terminal-width")
+
+	t.height, _ = theme.GetPropLength("terminal-height")
+
+	t.border, _ = theme.GetPropNumber("terminal-border")
+	return t
+}
+
+// termScreen 终端屏幕上的内容，每行不超过终端的列数
+type termScreen struct {
+	lines   []string
+	cursorX int
+	cursorY int
+}
+
+// termScreenFunc 根据终端的列数和行数生成屏幕内容
+type termScreenFunc func(columns, rows int) *termScreen
+
+// getCharSize 参考 gfxterm，字符宽度取 ASCII 字符中最宽的，高度是 ascent + descent
+func getCharSize(fontFace *font.Face) (width, height int) {
+	for r := rune(0x20); r < 0x7f; r++ {
+		advance, ok := fontFace.GlyphAdvance(r)
+		if ok && advance.Round() > width {
+			width = advance.Round()
+		}
+	}
+	if width == 0 {
+		width = fontFace.MaxWidth
+	}
+	return width, fontFace.Height()
+}
+
+// toNode 返回 terminal box 的节点，它的子节点是终端的文字窗口
+func (t *Terminal) toNode(parent *Node, getScreen termScreenFunc) *Node {
+	padLeft, padRight, padTop, padBottom := getPads(t.box)
+
+	// 文字窗口的位置和大小
+	winLeft := getLengthExpr(t.left, nodeWidth{parent})
+	winTop := getLengthExpr(t.top, nodeHeight{parent})
+	winWidth := getLengthExpr(t.width, nodeWidth{parent})
+	winHeight := getLengthExpr(t.height, nodeHeight{parent})
+
+	boxNode := &Node{
+		leftExpr:   sub(winLeft, AbsNum(padLeft)),
+		topExpr:    sub(winTop, AbsNum(padTop)),
+		widthExpr:  add(winWidth, AbsNum(padLeft+padRight)),
+		heightExpr: add(winHeight, AbsNum(padTop+padBottom)),
+	}
+	boxNode.draw = func(n *Node, ctx *gg.Context, ec *EvalContext) {
+		n.drawStyleBox(ctx, ec, t.box)
+	}
+
+	winNode := &Node{
+		leftExpr:   AbsNum(padLeft),
+		topExpr:    AbsNum(padTop),
+		widthExpr:  winWidth,
+		heightExpr: winHeight,
+	}
+	fontFace := getFont(t.font)
+	winNode.draw = func(n *Node, ctx *gg.Context, ec *EvalContext) {
+		t.drawScreen(n, ctx, ec, fontFace, getScreen)
+	}
+	boxNode.addChild(winNode)
+	return boxNode
+}
+
+func (t *Terminal) drawScreen(n *Node, ctx *gg.Context, ec *EvalContext,
+	fontFace *font.Face, getScreen termScreenFunc) {
+	x := int(n.getLeft().Eval(ec)) + t.border
+	y := int(n.getTop().Eval(ec)) + t.border
+	width := int(n.getWidth().Eval(ec)) - 2*t.border
+	height := int(n.getHeight().Eval(ec)) - 2*t.border
+
+	charWidth, charHeight := getCharSize(fontFace)
+	columns := width / charWidth
+	rows := height / charHeight
+	if columns <= 0 || rows <= 0 {
+		log.Println("WARN: terminal window is too small")
+		return
+	}
+	log.Printf("terminal columns: %d, rows: %d\n", columns, rows)
+
+	screen := getScreen(columns, rows)
+	for row, line := range screen.lines {
+		if row >= rows {
+			break
+		}
+		baseline := float64(y + row*charHeight + fontFace.Ascent)
+		col := 0
+		for _, r := range line {
+			if col >= columns {
+				break
+			}
+			if r != ' ' {
+				drawTextBaseline(ctx, string(r), float64(x+col*charWidth), baseline,
+					termNormalColor, fontFace)
+			}
+			col++
+		}
+	}
+
+	// 光标是字符下方高 2 像素的横线
+	if screen.cursorX >= 0 && screen.cursorY >= 0 {
+		ctx.SetColor(termNormalColor)
+		ctx.DrawRectangle(float64(x+screen.cursorX*charWidth),
+			float64(y+screen.cursorY*charHeight+fontFace.Ascent),
+			float64(charWidth), 2)
+		ctx.Fill()
+	}
+}
+
+// expandTabs 把 tab 展开为空格，tab 宽度是 8
+func expandTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var buf []rune
+	for _, r := range line {
+		if r == '\t' {
+			buf = append(buf, ' ')
+			for len(buf)%8 != 0 {
+				buf = append(buf, ' ')
+			}
+			continue
+		}
+		buf = append(buf, r)
+	}
+	return string(buf)
+}
+
+// splitLine 按终端的列数折行
+func splitLine(line string, columns int) []string {
+	runes := []rune(expandTabs(line))
+	if len(runes) == 0 {
+		return []string{""}
+	}
+	var result []string
+	for len(runes) > columns {
+		result = append(result, string(runes[:columns]))
+		runes = runes[columns:]
+	}
+	return append(result, string(runes))
+}
+
+// wrapText 参考 grub_print_message_indented，按单词折行，每行缩进 indent 列
+func wrapText(text string, columns, indent int) []string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(text) {
+		if line != "" && len([]rune(line))+1+len([]rune(word)) > columns-2*indent {
+			lines = append(lines, strings.Repeat(" ", indent)+line)
+			line = ""
+		}
+		if line == "" {
+			line = word
+		} else {
+			line += " " + word
+		}
+	}
+	if line != "" {
+		lines = append(lines, strings.Repeat(" ", indent)+line)
+	}
+	return lines
+}
+
+// centerText 让文字在终端中居中
+func centerText(text string, columns int) string {
+	n := (columns - len([]rune(text))) / 2
+	if n < 0 {
+		n = 0
+	}
+	return strings.Repeat(" ", n) + text
+}
+
+// getBannerLines 参考 grub_normal_init_page，返回终端顶部的版本信息
+func getBannerLines(columns int) []string {
+	return []string{
+		"",
+		centerText(fmt.Sprintf(gettextGrub("GNU GRUB  version %s"), grubVersion), columns),
+		"",
+	}
+}
+
+const consoleHelpMessage = "Minimal BASH-like line editing is supported. " +
+	"For the first word, TAB lists possible command completions. " +
+	"Anywhere else TAB lists possible device or file completions. " +
+	"ESC at any time exits."
+
+// getConsoleTranscript 返回命令行界面显示的文字，最后一行是当前的输入行
+func getConsoleTranscript(columns int) []string {
+	if optTranscript != "" {
+		content, err := ioutil.ReadFile(optTranscript)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	}
+
+	lines := getBannerLines(columns)
+	lines = append(lines, wrapText(gettextGrub(consoleHelpMessage), columns, 3)...)
+	lines = append(lines, "", "", "grub> ")
+	return lines
+}
+
+// getConsoleScreen 模拟命令行的输出，内容超过行数时向上滚动
+func getConsoleScreen(columns, rows int) *termScreen {
+	var lines []string
+	for _, line := range getConsoleTranscript(columns) {
+		lines = append(lines, splitLine(line, columns)...)
+	}
+
+	// 光标在最后一行的末尾
+	cursorX := len([]rune(lines[len(lines)-1]))
+	if cursorX >= columns {
+		lines = append(lines, "")
+		cursorX = 0
+	}
+	if len(lines) > rows {
+		lines = lines[len(lines)-rows:]
+	}
+	return &termScreen{
+		lines:   lines,
+		cursorX: cursorX,
+		cursorY: len(lines) - 1,
+	}
+}
+
+// consoleToNodeTree 返回命令行界面的节点树
+func consoleToNodeTree(theme *tt.Theme) *Node {
+	root := &Node{}
+	terminal := newTerminal(theme)
+	root.addChild(terminal.toNode(root, getConsoleScreen))
+	return root
+}
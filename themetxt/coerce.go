@@ -0,0 +1,164 @@
+package themetxt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// grub 把所有属性值都保存为字符串，在使用的地方再解释，
+// 这里的函数按照 grub 的方式把属性值转换为需要的类型。
+
+// Name 返回属性名
+func (p *Property) Name() string {
+	return p.name
+}
+
+// Value 返回解析得到的属性值
+func (p *Property) Value() interface{} {
+	return p.value
+}
+
+// Raw 返回属性值在源文件中的原文，包括引号。
+// 通过 SetProp 设置的属性没有原文，返回值和输出时一样。
+func (p *Property) Raw() string {
+	if p.raw != "" {
+		return p.raw
+	}
+	return propValueToString(p.value)
+}
+
+// GrubString 返回 grub 看到的属性值，即引号中的内容或者不加引号的原文
+func (p *Property) GrubString() string {
+	if p.raw == "" {
+		if str, ok := p.value.(string); ok {
+			return str
+		}
+		return propValueToString(p.value)
+	}
+	return getGrubString(p.raw)
+}
+
+// getGrubString 返回属性值原文对应的 grub 看到的值
+func getGrubString(raw string) string {
+	if strings.HasPrefix(raw, `"`) {
+		return unquoteString([]byte(raw))
+	}
+	return raw
+}
+
+// quoteString 给字符串加上引号，是 unquoteString 的逆操作，只转义引号和会和后面的
+// 字符组成转义的反斜杠。grub 的 read_expression 不处理转义，遇到下一个引号字符串就结束，
+// 所以包含引号或反斜杠的值写出来 grub 不一定读得一样，Format 对这样的原文保留不变。
+func quoteString(str string) string {
+	var buf strings.Builder
+	buf.WriteByte('"')
+	for i := 0; i < len(str); i++ {
+		switch str[i] {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			if i+1 == len(str) || str[i+1] == '"' || str[i+1] == '\\' {
+				buf.WriteString(`\\`)
+			} else {
+				buf.WriteByte('\\')
+			}
+		default:
+			buf.WriteByte(str[i])
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+// parseGrubInt 相当于 grub_strtol(str, 0, 10)，跳过开头的空白，忽略数字后面的内容，
+// 没有数字时返回 0。complete 表示整个字符串是否都是数字。
+func parseGrubInt(str string) (val int, complete bool) {
+	str = strings.TrimLeft(str, " \t\r\n")
+	neg := false
+	if strings.HasPrefix(str, "-") {
+		neg = true
+		str = str[1:]
+	} else if strings.HasPrefix(str, "+") {
+		str = str[1:]
+	}
+	val, rest, ok := scanUint(str, 10)
+	if neg {
+		val = -val
+	}
+	return val, ok && rest == ""
+}
+
+var errInvalidLength = errors.New("invalid number")
+
+// parseProportionalSpec 参考 grub 的 parse_proportional_spec，
+// 值是若干个可以带正负号的项，带 % 的项是相对长度，其他的是绝对长度，分别相加。
+func parseProportionalSpec(str string) (abs, rel int, err error) {
+	for str != "" {
+		neg := false
+		for strings.HasPrefix(str, "-") || strings.HasPrefix(str, "+") {
+			if str[0] == '-' {
+				neg = !neg
+			}
+			str = str[1:]
+		}
+
+		num, rest, ok := scanUint(str, 0)
+		if !ok {
+			return 0, 0, errInvalidLength
+		}
+		str = rest
+		if neg {
+			num = -num
+		}
+		if strings.HasPrefix(str, "%") {
+			rel += num
+			str = str[1:]
+		} else {
+			abs += num
+		}
+	}
+	return abs, rel, nil
+}
+
+// toLength 把绝对长度和相对长度转换为 Length
+func toLength(abs, rel int) Length {
+	switch {
+	case rel == 0:
+		return AbsNum(abs)
+	case abs == 0:
+		return RelNum(rel)
+	case abs < 0:
+		return CombinedNum{Rel: rel, Abs: -abs, Op: CombinedNumSub}
+	default:
+		return CombinedNum{Rel: rel, Abs: abs, Op: CombinedNumAdd}
+	}
+}
+
+// ParseLength 按照 grub 的方式解析长度，比如 "50%-10"
+func ParseLength(str string) (Length, error) {
+	abs, rel, err := parseProportionalSpec(str)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %q", err, str)
+	}
+	return toLength(abs, rel), nil
+}
+
+// GetBool 按照 grub 的方式把属性值转换为 bool：默认值为 true 的属性只有值是
+// "false" 时才是 false，默认值为 false 的属性只有值是 "true" 时才是 true。
+func GetBool(str string, defaultValue bool) bool {
+	if defaultValue {
+		return str != "false"
+	}
+	return str == "true"
+}
+
+func findProp(props []*Property, name string) *Property {
+	// grub 中后设置的属性覆盖前面的
+	for i := len(props) - 1; i >= 0; i-- {
+		if props[i].name == name {
+			return props[i]
+		}
+	}
+	return nil
+}
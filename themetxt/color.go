@@ -0,0 +1,158 @@
+package themetxt
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+//go:embed svgcolors.txt
+var svgColorsData []byte
+
+var svgColorMap map[string]color.Color
+
+// loadSvgColorMap 读取 grub 支持的颜色名，和 grub 的 named_colors 一样是 SVG 的颜色
+func loadSvgColorMap() {
+	if svgColorMap != nil {
+		return
+	}
+
+	svgColorMap = make(map[string]color.Color)
+	scanner := bufio.NewScanner(bytes.NewReader(svgColorsData))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		c, err := parseHexColor(strings.TrimPrefix(fields[1], "#"))
+		if err == nil {
+			svgColorMap[fields[0]] = c
+		}
+	}
+}
+
+// ParseColor 参考 grub 的 grub_video_parse_color，颜色可以是 #RGB、#RGBA、#RRGGBB、
+// #RRGGBBAA，逗号分开的十进制数 r,g,b[,a]，或者颜色名。grub 无法解析颜色时加载主题失败。
+func ParseColor(str string) (color.Color, error) {
+	s := strings.TrimLeft(str, " \t\r\n")
+	if strings.HasPrefix(s, "#") {
+		c, err := parseHexColor(s[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid color specification %q", str)
+		}
+		return c, nil
+	}
+
+	if s != "" && s[0] >= '0' && s[0] <= '9' {
+		c, err := parseDecimalColor(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid color specification %q", str)
+		}
+		return c, nil
+	}
+
+	loadSvgColorMap()
+	c, ok := svgColorMap[s]
+	if !ok {
+		return nil, fmt.Errorf("invalid named color %q", s)
+	}
+	return c, nil
+}
+
+// parseHexColor 解析 # 后面的十六进制数，只看开头的十六进制数字，后面的内容忽略
+func parseHexColor(s string) (color.Color, error) {
+	n := 0
+	for n < len(s) && getDigit(s[n]) < 16 {
+		n++
+	}
+
+	// 一位数字时重复一次，比如 #f00 等于 #ff0000
+	var size int
+	switch n {
+	case 3, 4:
+		size = 1
+	case 6, 8:
+		size = 2
+	default:
+		return nil, fmt.Errorf("%d hex digits", n)
+	}
+
+	c := color.NRGBA{A: 255}
+	components := []*uint8{&c.R, &c.G, &c.B, &c.A}
+	for i := 0; i < n/size; i++ {
+		part := s[i*size : (i+1)*size]
+		if size == 1 {
+			part += part
+		}
+		val, _, _ := scanUint(part, 16)
+		*components[i] = uint8(val)
+	}
+	return c, nil
+}
+
+// parseDecimalColor 解析 r,g,b[,a]，每个数字都和 grub_strtoul 一样解析，
+// 数字后面到逗号之前的内容忽略，第三个数字后面没有逗号时不透明。
+func parseDecimalColor(s string) (color.Color, error) {
+	c := color.NRGBA{A: 255}
+	components := []*uint8{&c.R, &c.G, &c.B, &c.A}
+	for i, component := range components {
+		val, rest, ok := scanUint(strings.TrimLeft(s, " \t\r\n"), 0)
+		if !ok {
+			return nil, fmt.Errorf("number expected in %q", s)
+		}
+		*component = uint8(val)
+
+		idx := strings.IndexByte(rest, ',')
+		if idx < 0 {
+			if i < 2 {
+				return nil, fmt.Errorf("comma expected")
+			}
+			break
+		}
+		s = rest[idx+1:]
+	}
+	return c, nil
+}
+
+// scanUint 参考 grub_strtoul，从 str 的开头读取一个无符号整数，base 为 0 时
+// 0x 开头的是十六进制，0 开头的是八进制。返回读到的值和剩下的内容。
+func scanUint(str string, base int) (val int, rest string, ok bool) {
+	if base == 0 {
+		base = 10
+		if strings.HasPrefix(str, "0x") || strings.HasPrefix(str, "0X") {
+			// 0x 后面没有十六进制数字时按 0 处理，和 grub 一样
+			if len(str) > 2 && getDigit(str[2]) < 16 {
+				base = 16
+				str = str[2:]
+			}
+		} else if strings.HasPrefix(str, "0") {
+			base = 8
+		}
+	}
+
+	i := 0
+	for ; i < len(str); i++ {
+		d := getDigit(str[i])
+		if d >= base {
+			break
+		}
+		val = val*base + d
+	}
+	return val, str[i:], i > 0
+}
+
+// getDigit 返回字符表示的数字，不是数字时返回 36
+func getDigit(c byte) int {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0')
+	case 'a' <= c && c <= 'z':
+		return int(c-'a') + 10
+	case 'A' <= c && c <= 'Z':
+		return int(c-'A') + 10
+	}
+	return 36
+}
@@ -0,0 +1,53 @@
+package themetxt
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		str  string
+		want color.NRGBA
+	}{
+		{"#f00", color.NRGBA{R: 0xff, A: 0xff}},
+		{"#f008", color.NRGBA{R: 0xff, A: 0x88}},
+		{"#3c50c8", color.NRGBA{R: 0x3c, G: 0x50, B: 0xc8, A: 0xff}},
+		{"#3C50C880", color.NRGBA{R: 0x3c, G: 0x50, B: 0xc8, A: 0x80}},
+		{" #abc and more", color.NRGBA{R: 0xaa, G: 0xbb, B: 0xcc, A: 0xff}},
+		{"128,128,128", color.NRGBA{R: 128, G: 128, B: 128, A: 255}},
+		{"1, 2, 3, 4", color.NRGBA{R: 1, G: 2, B: 3, A: 4}},
+		{"0x10,010,5px", color.NRGBA{R: 16, G: 8, B: 5, A: 255}},
+		{"white", color.NRGBA{R: 255, G: 255, B: 255, A: 255}},
+		{"cornflowerblue", color.NRGBA{R: 100, G: 149, B: 237, A: 255}},
+	}
+	for _, test := range tests {
+		c, err := ParseColor(test.str)
+		if err != nil {
+			t.Errorf("%q: %v", test.str, err)
+			continue
+		}
+		if c != test.want {
+			t.Errorf("%q: got %v, want %v", test.str, c, test.want)
+		}
+	}
+}
+
+func TestParseColorError(t *testing.T) {
+	tests := []string{
+		"",
+		"#",
+		"#12",
+		"#12345",
+		"#1234567",
+		"1,2",
+		"1,,3",
+		"White",
+		"no-such-color",
+	}
+	for _, str := range tests {
+		if c, err := ParseColor(str); err == nil {
+			t.Errorf("%q: expected error, got %v", str, c)
+		}
+	}
+}
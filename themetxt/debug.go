@@ -0,0 +1,12 @@
+package themetxt
+
+import "log"
+
+// Verbose 为 true 时，解析过程中输出读到的注释、属性和组件
+var Verbose bool
+
+func debugf(format string, args ...interface{}) {
+	if Verbose {
+		log.Printf(format, args...)
+	}
+}
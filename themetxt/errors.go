@@ -0,0 +1,114 @@
+package themetxt
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ParseError 是 theme.txt 中的语法错误，Line 和 Col 从 1 开始
+type ParseError struct {
+	Filename string
+	Line     int
+	Col      int
+	Msg      string
+	// 出错的那一行
+	Source string
+	// 这个位置可以出现的内容
+	Expected []string
+}
+
+// Error 返回错误的位置和说明，接着是出错的那一行，用 ^ 标出位置
+func (e *ParseError) Error() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s:%d:%d: %s", e.Filename, e.Line, e.Col, e.Msg)
+	if e.Line > 0 {
+		buf.WriteString("\n\t" + e.Source + "\n\t" + e.caretLine())
+	}
+	if len(e.Expected) > 0 {
+		buf.WriteString("\nexpected one of: " + strings.Join(e.Expected, ", "))
+	}
+	return buf.String()
+}
+
+// caretLine 返回标出错误位置的行，tab 保持不变以便和源码对齐
+func (e *ParseError) caretLine() string {
+	var buf bytes.Buffer
+	col := 1
+	for _, r := range e.Source {
+		if col >= e.Col {
+			break
+		}
+		if r == '\t' {
+			buf.WriteRune('\t')
+		} else {
+			buf.WriteRune(' ')
+		}
+		col++
+	}
+	buf.WriteRune('^')
+	return buf.String()
+}
+
+// getSourceLine 返回第 line 行的内容，不含换行符
+func getSourceLine(data []byte, line int) string {
+	lines := bytes.Split(data, []byte("\n"))
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return strings.TrimRight(string(lines[line-1]), "\r")
+}
+
+// describeExpected 把 pigeon 给出的期望转换为容易阅读的形式
+func describeExpected(expected []string) []string {
+	var result []string
+	set := make(map[string]bool)
+	for _, item := range expected {
+		switch item {
+		case "!.", "EOF":
+			item = "end of file"
+		case `"\n"`:
+			item = "newline"
+		}
+		if !set[item] {
+			set[item] = true
+			result = append(result, item)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// newParseError 把 pigeon 返回的错误转换为 *ParseError，
+// 有多个错误时只使用第一个。
+func newParseError(filename string, data []byte, err error) error {
+	list, ok := err.(errList)
+	if !ok || len(list) == 0 {
+		return err
+	}
+	pe, ok := list[0].(*parserError)
+	if !ok {
+		return err
+	}
+
+	result := &ParseError{
+		Filename: filename,
+		Line:     pe.pos.line,
+		Col:      pe.pos.col,
+		Source:   getSourceLine(data, pe.pos.line),
+		Expected: describeExpected(pe.expected),
+	}
+	if len(pe.expected) > 0 {
+		result.Msg = "syntax error"
+		if pe.pos.offset >= len(data) {
+			result.Msg = "syntax error: unexpected end of file"
+		} else {
+			result.Msg = fmt.Sprintf("syntax error: unexpected %q",
+				string(bytes.Runes(data[pe.pos.offset:])[0]))
+		}
+	} else {
+		result.Msg = pe.Inner.Error()
+	}
+	return result
+}
@@ -0,0 +1,274 @@
+package themetxt
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+)
+
+// 规范格式：
+//   - 先是全局属性，然后是组件，组件之间空一行
+//   - 组件中先是属性，然后是子组件，子组件前面空一行，每层缩进 4 个空格
+//   - 属性按照属性描述中的顺序排列，未知的属性放在后面，保持原来的顺序
+//   - 属性值使用 propValueToString 的写法，但不改变 grub 看到的值
+//   - 注释跟随它后面的语句，语句后面同一行的注释保留在同一行，
+//     注释之间和注释与语句之间的空行保留一个，文件开头和后面隔着空行的注释留在开头
+
+const formatIndent = "    "
+
+// fmtItem 是一个属性或组件，和它前面的注释、同一行后面的注释
+type fmtItem struct {
+	node     Node
+	leading  []*Comment
+	trailing *Comment
+	// leading 中每个注释前面是否有空行，第一个注释前面的空行只在属性之间和块末尾保留
+	blankBefore []bool
+	// leading 和语句之间是否有空行
+	blankAfter bool
+}
+
+type formatter struct {
+	source []byte
+	buf    bytes.Buffer
+}
+
+// sameLine 判断源文件中 [start, end) 之间是否没有换行
+func (f *formatter) sameLine(start, end int) bool {
+	if f.source == nil || start > end {
+		return false
+	}
+	return bytes.IndexByte(f.source[start:end], '\n') == -1
+}
+
+// hasBlankLine 判断源文件中 [start, end) 之间是否有空行
+func (f *formatter) hasBlankLine(start, end int) bool {
+	if f.source == nil || start > end {
+		return false
+	}
+	return bytes.Count(f.source[start:end], []byte("\n")) >= 2
+}
+
+// group 把注释分配给语句，openEnd 是块开始的位置，和它在同一行的注释放在 open 中，
+// 块末尾的注释放在 tail 中
+func (f *formatter) group(nodes []Node, openEnd int) (open *Comment, items []*fmtItem,
+	tail *fmtItem) {
+	var pending []*Comment
+	var blankBefore []bool
+	prevEnd := openEnd
+	var prev *fmtItem
+	for _, node := range nodes {
+		start, end := node.Span()
+		blank := f.hasBlankLine(prevEnd, start)
+
+		if comment, ok := node.(*Comment); ok {
+			if len(pending) == 0 && f.sameLine(prevEnd, start) {
+				if prev != nil && prev.trailing == nil {
+					prev.trailing = comment
+					prevEnd = end
+					continue
+				}
+				if prev == nil && open == nil && openEnd > 0 {
+					open = comment
+					prevEnd = end
+					continue
+				}
+			}
+			pending = append(pending, comment)
+			blankBefore = append(blankBefore, blank)
+			prevEnd = end
+			continue
+		}
+
+		prev = &fmtItem{
+			node:        node,
+			leading:     pending,
+			blankBefore: blankBefore,
+			blankAfter:  len(pending) > 0 && blank,
+		}
+		items = append(items, prev)
+		pending = nil
+		blankBefore = nil
+		prevEnd = end
+	}
+	if len(pending) > 0 {
+		tail = &fmtItem{leading: pending, blankBefore: blankBefore}
+	}
+	return
+}
+
+// getNodes 返回源文件中的语句，加上通过 SetProp 等方式新加的属性和组件
+func getNodes(nodes []Node, props []*Property, comps []*Component) []Node {
+	result := append([]Node(nil), nodes...)
+	inNodes := make(map[Node]bool)
+	for _, node := range nodes {
+		inNodes[node] = true
+	}
+	for _, prop := range props {
+		if !inNodes[prop] {
+			result = append(result, prop)
+		}
+	}
+	for _, comp := range comps {
+		if !inNodes[comp] {
+			result = append(result, comp)
+		}
+	}
+	return result
+}
+
+// getPropRank 返回属性在属性描述中的位置，未知的属性排在最后
+func getPropRank(compType, name string) int {
+	var specs []*PropSpec
+	if compType == "" {
+		specs = GlobalPropSpecs
+	} else {
+		specs = commonPropSpecs
+		if cs, ok := ComponentSpecs[compType]; ok {
+			specs = append(specs[:len(specs):len(specs)], cs.Props...)
+		}
+	}
+	for i, spec := range specs {
+		if spec.Name == name {
+			return i
+		}
+	}
+	return len(specs)
+}
+
+// sortItems 把属性按照属性描述排在前面，组件保持原来的顺序排在后面
+func sortItems(compType string, items []*fmtItem) {
+	rank := func(item *fmtItem) int {
+		prop, ok := item.node.(*Property)
+		if !ok {
+			return len(GlobalPropSpecs) + len(commonPropSpecs) + 1000
+		}
+		return getPropRank(compType, prop.name)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return rank(items[i]) < rank(items[j])
+	})
+}
+
+// formatValue 返回属性值的规范写法。长度属性的值和 grub 一样按 parse_proportional_spec
+// 解释，可以改写，比如 50%+-5 改写为 50%-5；其他属性的规范写法会改变 grub 看到的值时，
+// 比如 title-text: 0x10，使用原文。
+// 带反斜杠的字符串也使用原文，grub 的 read_expression 不处理转义，改写 "a\\b" 这样的值
+// 会改变 grub 看到的内容。
+func formatValue(compType string, prop *Property) string {
+	if strings.HasPrefix(prop.raw, `"`) && strings.Contains(prop.raw, `\`) {
+		return prop.raw
+	}
+	text := propValueToString(prop.value)
+	if prop.raw == "" || getGrubString(text) == prop.GrubString() {
+		return text
+	}
+	if _, ok := prop.value.(Length); ok {
+		if spec := GetPropSpec(compType, prop.name); spec != nil && spec.Type == PropTypeLength {
+			return text
+		}
+	}
+	return prop.raw
+}
+
+// writeComments 输出语句前面的注释，first 为 true 时不输出第一个注释前面的空行
+func (f *formatter) writeComments(item *fmtItem, indent string, first bool) {
+	for i, comment := range item.leading {
+		if item.blankBefore[i] && !(i == 0 && first) {
+			f.buf.WriteString("\n")
+		}
+		f.buf.WriteString(indent + comment.Text + "\n")
+	}
+	if item.blankAfter {
+		f.buf.WriteString("\n")
+	}
+}
+
+func (f *formatter) writeTrailing(comment *Comment) {
+	if comment != nil {
+		f.buf.WriteString(" " + comment.Text)
+	}
+	f.buf.WriteString("\n")
+}
+
+// writeItems 输出一个块中的语句，组件前面空一行
+func (f *formatter) writeItems(items []*fmtItem, tail *fmtItem, compType, sep, indent string) {
+	for i, item := range items {
+		_, isComp := item.node.(*Component)
+		if isComp && i > 0 {
+			f.buf.WriteString("\n")
+		}
+		f.writeComments(item, indent, isComp || i == 0)
+		switch node := item.node.(type) {
+		case *Property:
+			f.buf.WriteString(indent + node.name + sep + formatValue(compType, node))
+			f.writeTrailing(item.trailing)
+		case *Component:
+			f.writeComponent(node, item.trailing, indent)
+		}
+	}
+	if tail != nil {
+		f.writeComments(tail, indent, len(items) == 0)
+	}
+}
+
+func (f *formatter) writeComponent(c *Component, trailing *Comment, indent string) {
+	nodes := getNodes(c.Nodes, c.Props, c.Children)
+	openEnd := 0
+	if c.span.hasSource() {
+		openEnd = c.span.start + bytes.IndexByte(f.source[c.span.start:], '{') + 1
+	}
+	open, items, tail := f.group(nodes, openEnd)
+	sortItems(c.Type, items)
+
+	f.buf.WriteString(indent + "+ " + c.Type + " {")
+	f.writeTrailing(open)
+	f.writeItems(items, tail, c.Type, " = ", indent+formatIndent)
+	f.buf.WriteString(indent + "}")
+	f.writeTrailing(trailing)
+}
+
+// writeHeader 输出文件开头的注释，即第一个语句前面和它隔着空行的注释，
+// 这些注释留在文件开头，不随第一个语句移动
+func (f *formatter) writeHeader(first *fmtItem) {
+	n := 0
+	if first.blankAfter {
+		n = len(first.leading)
+	} else {
+		for i := len(first.leading) - 1; i > 0; i-- {
+			if first.blankBefore[i] {
+				n = i
+				break
+			}
+		}
+	}
+	if n == 0 {
+		return
+	}
+	header := &fmtItem{leading: first.leading[:n], blankBefore: first.blankBefore[:n]}
+	f.writeComments(header, "", true)
+	f.buf.WriteString("\n")
+
+	first.leading = first.leading[n:]
+	first.blankBefore = first.blankBefore[n:]
+	first.blankAfter = first.blankAfter && len(first.leading) > 0
+}
+
+// Format 按规范格式输出主题，保留注释，不改变 grub 看到的属性值和组件结构。
+// 换行符统一为 \n，源文件开头的 BOM 保留。
+func (t *Theme) Format(w io.Writer) {
+	f := &formatter{source: t.source}
+	nodes := getNodes(t.Nodes, t.Props, t.Components)
+	if bytes.HasPrefix(t.source, bom) {
+		f.buf.Write(bom)
+	}
+
+	_, items, tail := f.group(nodes, 0)
+	if len(items) > 0 {
+		f.writeHeader(items[0])
+	}
+	sortItems("", items)
+	f.writeItems(items, tail, "", ": ", "")
+
+	w.Write(f.buf.Bytes())
+}
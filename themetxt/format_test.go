@@ -0,0 +1,192 @@
+package themetxt
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func formatString(t *testing.T, src string) string {
+	t.Helper()
+	theme, err := ParseTheme("theme.txt", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	theme.Format(&buf)
+	return buf.String()
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "desktop image",
+			// grub 读到 desktop-image 时就缩放图片，缩放方式和对齐方式要在它前面
+			src: `desktop-color: "#000000"
+desktop-image: "bg.png"
+desktop-image-v-align: "top"
+title-text: ""
+desktop-image-scale-method: "fitwidth"
+desktop-image-h-align: "left"
+`,
+			want: `title-text: ""
+desktop-image-scale-method: "fitwidth"
+desktop-image-h-align: "left"
+desktop-image-v-align: "top"
+desktop-image: "bg.png"
+desktop-color: "#000000"
+`,
+		},
+		{
+			name: "layout",
+			src: `+ vbox{+ label{text="a" top=10 left=50%+-5}
++ image { file = "x.png" } width=100}
+title-text:GRUB
+`,
+			want: `title-text: "GRUB"
+
++ vbox {
+    width = 100
+
+    + label {
+        left = 50%-5
+        top = 10
+        text = "a"
+    }
+
+    + image {
+        file = "x.png"
+    }
+}
+`,
+		},
+		{
+			name: "comments",
+			src: `# header
+
+# about the title
+title-text: "GRUB" # same line
+# about the menu
++ boot_menu { # open
+    # item height
+    item_height = 30
+    left = 10
+    # end of menu
+}
+# end of file
+`,
+			want: `# header
+
+# about the title
+title-text: "GRUB" # same line
+
+# about the menu
++ boot_menu { # open
+    left = 10
+    # item height
+    item_height = 30
+    # end of menu
+}
+# end of file
+`,
+		},
+		{
+			name: "values",
+			// 规范写法会改变 grub 看到的值时保留原文，带反斜杠的字符串也保留原文
+			src: `title-text: 0x10
+message-color: white
++ label { text = "C:\\dir\\" color = "a\"b" left = "10" }
++ label { text = "C:\dir" }
+`,
+			want: `title-text: 0x10
+message-color: "white"
+
++ label {
+    left = "10"
+    text = "C:\\dir\\"
+    color = "a\"b"
+}
+
++ label {
+    text = "C:\dir"
+}
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := formatString(t, test.src)
+			if got != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+// TestFormatIdempotent 检查格式化 testdata 中的主题两次得到的结果相同，
+// 并且格式化不改变 grub 看到的属性值
+func TestFormatIdempotent(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		file := file
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			theme, err := ParseThemeFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			theme.Format(&buf)
+			once := buf.String()
+
+			formatted, err := ParseTheme(file, []byte(once))
+			if err != nil {
+				t.Fatalf("formatted theme does not parse: %v\n%s", err, once)
+			}
+			if got, want := getGrubValues(formatted), getGrubValues(theme); got != want {
+				t.Errorf("values changed:\ngot:\n%s\nwant:\n%s", got, want)
+			}
+			if twice := formatString(t, once); twice != once {
+				t.Errorf("second format differs:\ngot:\n%s\nwant:\n%s", twice, once)
+			}
+		})
+	}
+}
+
+// getGrubValues 列出组件结构和 grub 看到的属性值，属性按名称排序，
+// 长度属性列出绝对部分和相对部分
+func getGrubValues(theme *Theme) string {
+	var buf bytes.Buffer
+	writeGrubValues(&buf, "", theme.Props, theme.Components, "")
+	return buf.String()
+}
+
+func writeGrubValues(buf *bytes.Buffer, compType string, props []*Property, comps []*Component,
+	indent string) {
+	sorted := append([]*Property(nil), props...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].name < sorted[j].name
+	})
+	for _, prop := range sorted {
+		value := prop.GrubString()
+		if spec := GetPropSpec(compType, prop.name); spec != nil && spec.Type == PropTypeLength {
+			if l, err := ParseLength(value); err == nil {
+				abs, rel := GetLengthParts(l)
+				value = fmt.Sprintf("%d%%%+d", rel, abs)
+			}
+		}
+		fmt.Fprintf(buf, "%s%s %q\n", indent, prop.name, value)
+	}
+	for _, comp := range comps {
+		fmt.Fprintf(buf, "%s+ %s\n", indent, comp.Type)
+		writeGrubValues(buf, comp.Type, comp.Props, comp.Children, indent+"    ")
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"math"
 	"os"
 	"sort"
@@ -25,34 +24,56 @@ func toIfaceSlice(v interface{}) []interface{} {
 	return v.([]interface{})
 }
 
+// unquoteString 去掉字符串两边的引号，只处理 \" 和 \\ 两种转义，
+// 其他的反斜杠和 grub 一样原样保留
+func unquoteString(text []byte) string {
+	text = text[1 : len(text)-1]
+	var buf bytes.Buffer
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) &&
+			(text[i+1] == '"' || text[i+1] == '\\') {
+			i++
+		}
+		buf.WriteByte(text[i])
+	}
+	return buf.String()
+}
+
 var g = &grammar{
 	rules: []*rule{
 		{
 			name: "Theme",
-			pos:  position{line: 14, col: 1, offset: 192},
+			pos:  position{line: 27, col: 1, offset: 694},
 			expr: &actionExpr{
-				pos: position{line: 14, col: 10, offset: 201},
+				pos: position{line: 27, col: 10, offset: 703},
 				run: (*parser).callonTheme1,
 				expr: &seqExpr{
-					pos: position{line: 14, col: 10, offset: 201},
+					pos: position{line: 27, col: 10, offset: 703},
 					exprs: []interface{}{
+						&zeroOrOneExpr{
+							pos: position{line: 27, col: 10, offset: 703},
+							expr: &ruleRefExpr{
+								pos:  position{line: 27, col: 10, offset: 703},
+								name: "BOM",
+							},
+						},
 						&ruleRefExpr{
-							pos:  position{line: 14, col: 10, offset: 201},
+							pos:  position{line: 27, col: 15, offset: 708},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 14, col: 12, offset: 203},
+							pos:   position{line: 27, col: 17, offset: 710},
 							label: "statements",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 14, col: 23, offset: 214},
+								pos: position{line: 27, col: 28, offset: 721},
 								expr: &ruleRefExpr{
-									pos:  position{line: 14, col: 23, offset: 214},
+									pos:  position{line: 27, col: 28, offset: 721},
 									name: "Statement",
 								},
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 14, col: 34, offset: 225},
+							pos:  position{line: 27, col: 39, offset: 732},
 							name: "EOF",
 						},
 					},
@@ -61,36 +82,36 @@ var g = &grammar{
 		},
 		{
 			name: "Statement",
-			pos:  position{line: 28, col: 1, offset: 544},
+			pos:  position{line: 42, col: 1, offset: 1103},
 			expr: &actionExpr{
-				pos: position{line: 28, col: 14, offset: 557},
+				pos: position{line: 42, col: 14, offset: 1116},
 				run: (*parser).callonStatement1,
 				expr: &seqExpr{
-					pos: position{line: 28, col: 14, offset: 557},
+					pos: position{line: 42, col: 14, offset: 1116},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 28, col: 14, offset: 557},
+							pos:   position{line: 42, col: 14, offset: 1116},
 							label: "s",
 							expr: &choiceExpr{
-								pos: position{line: 28, col: 17, offset: 560},
+								pos: position{line: 42, col: 17, offset: 1119},
 								alternatives: []interface{}{
 									&ruleRefExpr{
-										pos:  position{line: 28, col: 17, offset: 560},
+										pos:  position{line: 42, col: 17, offset: 1119},
 										name: "Comment",
 									},
 									&ruleRefExpr{
-										pos:  position{line: 28, col: 27, offset: 570},
+										pos:  position{line: 42, col: 27, offset: 1129},
 										name: "GPD",
 									},
 									&ruleRefExpr{
-										pos:  position{line: 28, col: 33, offset: 576},
+										pos:  position{line: 42, col: 33, offset: 1135},
 										name: "Component",
 									},
 								},
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 28, col: 45, offset: 588},
+							pos:  position{line: 42, col: 45, offset: 1147},
 							name: "_",
 						},
 					},
@@ -99,48 +120,48 @@ var g = &grammar{
 		},
 		{
 			name: "GPD",
-			pos:  position{line: 32, col: 1, offset: 613},
+			pos:  position{line: 46, col: 1, offset: 1172},
 			expr: &actionExpr{
-				pos: position{line: 32, col: 8, offset: 620},
+				pos: position{line: 46, col: 8, offset: 1179},
 				run: (*parser).callonGPD1,
 				expr: &seqExpr{
-					pos: position{line: 32, col: 8, offset: 620},
+					pos: position{line: 46, col: 8, offset: 1179},
 					exprs: []interface{}{
 						&ruleRefExpr{
-							pos:  position{line: 32, col: 8, offset: 620},
+							pos:  position{line: 46, col: 8, offset: 1179},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 32, col: 10, offset: 622},
+							pos:   position{line: 46, col: 10, offset: 1181},
 							label: "option",
 							expr: &ruleRefExpr{
-								pos:  position{line: 32, col: 17, offset: 629},
+								pos:  position{line: 46, col: 17, offset: 1188},
 								name: "Option",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 32, col: 24, offset: 636},
+							pos:  position{line: 46, col: 24, offset: 1195},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 32, col: 26, offset: 638},
+							pos:        position{line: 46, col: 26, offset: 1197},
 							val:        ":",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 32, col: 30, offset: 642},
+							pos:  position{line: 46, col: 30, offset: 1201},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 32, col: 32, offset: 644},
+							pos:   position{line: 46, col: 32, offset: 1203},
 							label: "val",
 							expr: &ruleRefExpr{
-								pos:  position{line: 32, col: 36, offset: 648},
+								pos:  position{line: 46, col: 36, offset: 1207},
 								name: "Value",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 32, col: 42, offset: 654},
+							pos:  position{line: 46, col: 42, offset: 1213},
 							name: "_",
 						},
 					},
@@ -149,48 +170,48 @@ var g = &grammar{
 		},
 		{
 			name: "CPD",
-			pos:  position{line: 41, col: 1, offset: 794},
+			pos:  position{line: 59, col: 1, offset: 1451},
 			expr: &actionExpr{
-				pos: position{line: 41, col: 8, offset: 801},
+				pos: position{line: 59, col: 8, offset: 1458},
 				run: (*parser).callonCPD1,
 				expr: &seqExpr{
-					pos: position{line: 41, col: 8, offset: 801},
+					pos: position{line: 59, col: 8, offset: 1458},
 					exprs: []interface{}{
 						&ruleRefExpr{
-							pos:  position{line: 41, col: 8, offset: 801},
+							pos:  position{line: 59, col: 8, offset: 1458},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 41, col: 10, offset: 803},
+							pos:   position{line: 59, col: 10, offset: 1460},
 							label: "option",
 							expr: &ruleRefExpr{
-								pos:  position{line: 41, col: 17, offset: 810},
+								pos:  position{line: 59, col: 17, offset: 1467},
 								name: "Option",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 41, col: 24, offset: 817},
+							pos:  position{line: 59, col: 24, offset: 1474},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 41, col: 26, offset: 819},
+							pos:        position{line: 59, col: 26, offset: 1476},
 							val:        "=",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 41, col: 30, offset: 823},
+							pos:  position{line: 59, col: 30, offset: 1480},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 41, col: 32, offset: 825},
+							pos:   position{line: 59, col: 32, offset: 1482},
 							label: "val",
 							expr: &ruleRefExpr{
-								pos:  position{line: 41, col: 36, offset: 829},
+								pos:  position{line: 59, col: 36, offset: 1486},
 								name: "Value",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 41, col: 42, offset: 835},
+							pos:  position{line: 59, col: 42, offset: 1492},
 							name: "_",
 						},
 					},
@@ -199,65 +220,65 @@ var g = &grammar{
 		},
 		{
 			name: "Component",
-			pos:  position{line: 48, col: 1, offset: 926},
+			pos:  position{line: 70, col: 1, offset: 1685},
 			expr: &actionExpr{
-				pos: position{line: 48, col: 14, offset: 939},
+				pos: position{line: 70, col: 14, offset: 1698},
 				run: (*parser).callonComponent1,
 				expr: &seqExpr{
-					pos: position{line: 48, col: 14, offset: 939},
+					pos: position{line: 70, col: 14, offset: 1698},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 48, col: 14, offset: 939},
+							pos:        position{line: 70, col: 14, offset: 1698},
 							val:        "+",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 48, col: 18, offset: 943},
+							pos:  position{line: 70, col: 18, offset: 1702},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 48, col: 20, offset: 945},
+							pos:   position{line: 70, col: 20, offset: 1704},
 							label: "type0",
 							expr: &ruleRefExpr{
-								pos:  position{line: 48, col: 26, offset: 951},
+								pos:  position{line: 70, col: 26, offset: 1710},
 								name: "ID",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 48, col: 29, offset: 954},
+							pos:  position{line: 70, col: 29, offset: 1713},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 48, col: 31, offset: 956},
+							pos:        position{line: 70, col: 31, offset: 1715},
 							val:        "{",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 48, col: 35, offset: 960},
+							pos:  position{line: 70, col: 35, offset: 1719},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 48, col: 38, offset: 963},
+							pos:   position{line: 70, col: 38, offset: 1722},
 							label: "elements",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 48, col: 47, offset: 972},
+								pos: position{line: 70, col: 47, offset: 1731},
 								expr: &ruleRefExpr{
-									pos:  position{line: 48, col: 47, offset: 972},
+									pos:  position{line: 70, col: 47, offset: 1731},
 									name: "ComponentElement",
 								},
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 48, col: 66, offset: 991},
+							pos:  position{line: 70, col: 66, offset: 1750},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 48, col: 68, offset: 993},
+							pos:        position{line: 70, col: 68, offset: 1752},
 							val:        "}",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 48, col: 72, offset: 997},
+							pos:  position{line: 70, col: 72, offset: 1756},
 							name: "_",
 						},
 					},
@@ -266,26 +287,26 @@ var g = &grammar{
 		},
 		{
 			name: "ComponentElement",
-			pos:  position{line: 66, col: 1, offset: 1394},
+			pos:  position{line: 90, col: 1, offset: 2228},
 			expr: &actionExpr{
-				pos: position{line: 66, col: 21, offset: 1414},
+				pos: position{line: 90, col: 21, offset: 2248},
 				run: (*parser).callonComponentElement1,
 				expr: &labeledExpr{
-					pos:   position{line: 66, col: 21, offset: 1414},
+					pos:   position{line: 90, col: 21, offset: 2248},
 					label: "ele",
 					expr: &choiceExpr{
-						pos: position{line: 66, col: 26, offset: 1419},
+						pos: position{line: 90, col: 26, offset: 2253},
 						alternatives: []interface{}{
 							&ruleRefExpr{
-								pos:  position{line: 66, col: 26, offset: 1419},
+								pos:  position{line: 90, col: 26, offset: 2253},
 								name: "Comment",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 66, col: 36, offset: 1429},
+								pos:  position{line: 90, col: 36, offset: 2263},
 								name: "CPD",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 66, col: 42, offset: 1435},
+								pos:  position{line: 90, col: 42, offset: 2269},
 								name: "Component",
 							},
 						},
@@ -295,43 +316,52 @@ var g = &grammar{
 		},
 		{
 			name: "Comment",
-			pos:  position{line: 70, col: 1, offset: 1471},
+			pos:  position{line: 94, col: 1, offset: 2305},
 			expr: &actionExpr{
-				pos: position{line: 70, col: 12, offset: 1482},
+				pos: position{line: 94, col: 12, offset: 2316},
 				run: (*parser).callonComment1,
 				expr: &seqExpr{
-					pos: position{line: 70, col: 14, offset: 1484},
+					pos: position{line: 94, col: 14, offset: 2318},
 					exprs: []interface{}{
 						&ruleRefExpr{
-							pos:  position{line: 70, col: 14, offset: 1484},
+							pos:  position{line: 94, col: 14, offset: 2318},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 70, col: 16, offset: 1486},
+							pos:        position{line: 94, col: 16, offset: 2320},
 							val:        "#",
 							ignoreCase: false,
 						},
 						&zeroOrMoreExpr{
-							pos: position{line: 70, col: 20, offset: 1490},
+							pos: position{line: 94, col: 20, offset: 2324},
 							expr: &seqExpr{
-								pos: position{line: 70, col: 21, offset: 1491},
+								pos: position{line: 94, col: 21, offset: 2325},
 								exprs: []interface{}{
 									&notExpr{
-										pos: position{line: 70, col: 21, offset: 1491},
+										pos: position{line: 94, col: 21, offset: 2325},
 										expr: &ruleRefExpr{
-											pos:  position{line: 70, col: 22, offset: 1492},
+											pos:  position{line: 94, col: 22, offset: 2326},
 											name: "NL",
 										},
 									},
 									&anyMatcher{
-										line: 70, col: 25, offset: 1495,
+										line: 94, col: 25, offset: 2329,
 									},
 								},
 							},
 						},
-						&ruleRefExpr{
-							pos:  position{line: 70, col: 29, offset: 1499},
-							name: "NL",
+						&choiceExpr{
+							pos: position{line: 94, col: 30, offset: 2334},
+							alternatives: []interface{}{
+								&ruleRefExpr{
+									pos:  position{line: 94, col: 30, offset: 2334},
+									name: "NL",
+								},
+								&ruleRefExpr{
+									pos:  position{line: 94, col: 35, offset: 2339},
+									name: "EOF",
+								},
+							},
 						},
 					},
 				},
@@ -339,26 +369,45 @@ var g = &grammar{
 		},
 		{
 			name: "NL",
-			pos:  position{line: 75, col: 1, offset: 1580},
+			pos:  position{line: 102, col: 1, offset: 2505},
+			expr: &choiceExpr{
+				pos: position{line: 102, col: 7, offset: 2511},
+				alternatives: []interface{}{
+					&litMatcher{
+						pos:        position{line: 102, col: 7, offset: 2511},
+						val:        "\r\n",
+						ignoreCase: false,
+					},
+					&litMatcher{
+						pos:        position{line: 102, col: 16, offset: 2520},
+						val:        "\n",
+						ignoreCase: false,
+					},
+				},
+			},
+		},
+		{
+			name: "BOM",
+			pos:  position{line: 104, col: 1, offset: 2526},
 			expr: &litMatcher{
-				pos:        position{line: 75, col: 7, offset: 1586},
-				val:        "\n",
+				pos:        position{line: 104, col: 8, offset: 2533},
+				val:        "\ufeff",
 				ignoreCase: false,
 			},
 		},
 		{
 			name: "Option",
-			pos:  position{line: 77, col: 1, offset: 1592},
+			pos:  position{line: 106, col: 1, offset: 2543},
 			expr: &actionExpr{
-				pos: position{line: 77, col: 11, offset: 1602},
+				pos: position{line: 106, col: 11, offset: 2553},
 				run: (*parser).callonOption1,
 				expr: &oneOrMoreExpr{
-					pos: position{line: 77, col: 11, offset: 1602},
+					pos: position{line: 106, col: 11, offset: 2553},
 					expr: &charClassMatcher{
-						pos:        position{line: 77, col: 11, offset: 1602},
-						val:        "[a-zA-Z_-]",
+						pos:        position{line: 106, col: 11, offset: 2553},
+						val:        "[a-zA-Z0-9_-]",
 						chars:      []rune{'_', '-'},
-						ranges:     []rune{'a', 'z', 'A', 'Z'},
+						ranges:     []rune{'a', 'z', 'A', 'Z', '0', '9'},
 						ignoreCase: false,
 						inverted:   false,
 					},
@@ -367,17 +416,17 @@ var g = &grammar{
 		},
 		{
 			name: "ID",
-			pos:  position{line: 81, col: 1, offset: 1650},
+			pos:  position{line: 110, col: 1, offset: 2604},
 			expr: &actionExpr{
-				pos: position{line: 81, col: 7, offset: 1656},
+				pos: position{line: 110, col: 7, offset: 2610},
 				run: (*parser).callonID1,
 				expr: &oneOrMoreExpr{
-					pos: position{line: 81, col: 7, offset: 1656},
+					pos: position{line: 110, col: 7, offset: 2610},
 					expr: &charClassMatcher{
-						pos:        position{line: 81, col: 7, offset: 1656},
-						val:        "[a-zA-Z_-]",
+						pos:        position{line: 110, col: 7, offset: 2610},
+						val:        "[a-zA-Z0-9_-]",
 						chars:      []rune{'_', '-'},
-						ranges:     []rune{'a', 'z', 'A', 'Z'},
+						ranges:     []rune{'a', 'z', 'A', 'Z', '0', '9'},
 						ignoreCase: false,
 						inverted:   false,
 					},
@@ -386,28 +435,32 @@ var g = &grammar{
 		},
 		{
 			name: "Value",
-			pos:  position{line: 85, col: 1, offset: 1704},
+			pos:  position{line: 114, col: 1, offset: 2661},
 			expr: &actionExpr{
-				pos: position{line: 85, col: 10, offset: 1713},
+				pos: position{line: 114, col: 10, offset: 2670},
 				run: (*parser).callonValue1,
 				expr: &labeledExpr{
-					pos:   position{line: 85, col: 10, offset: 1713},
+					pos:   position{line: 114, col: 10, offset: 2670},
 					label: "val",
 					expr: &choiceExpr{
-						pos: position{line: 85, col: 16, offset: 1719},
+						pos: position{line: 114, col: 16, offset: 2676},
 						alternatives: []interface{}{
 							&ruleRefExpr{
-								pos:  position{line: 85, col: 16, offset: 1719},
+								pos:  position{line: 114, col: 16, offset: 2676},
 								name: "Bool",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 85, col: 23, offset: 1726},
+								pos:  position{line: 114, col: 23, offset: 2683},
 								name: "Numeric",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 85, col: 33, offset: 1736},
+								pos:  position{line: 114, col: 33, offset: 2693},
 								name: "String",
 							},
+							&ruleRefExpr{
+								pos:  position{line: 114, col: 42, offset: 2702},
+								name: "Word",
+							},
 						},
 					},
 				},
@@ -415,174 +468,145 @@ var g = &grammar{
 		},
 		{
 			name: "Bool",
-			pos:  position{line: 89, col: 1, offset: 1770},
+			pos:  position{line: 122, col: 1, offset: 2822},
 			expr: &choiceExpr{
-				pos: position{line: 89, col: 9, offset: 1778},
+				pos: position{line: 122, col: 9, offset: 2830},
 				alternatives: []interface{}{
 					&actionExpr{
-						pos: position{line: 89, col: 9, offset: 1778},
+						pos: position{line: 122, col: 9, offset: 2830},
 						run: (*parser).callonBool2,
-						expr: &litMatcher{
-							pos:        position{line: 89, col: 9, offset: 1778},
-							val:        "true",
-							ignoreCase: false,
+						expr: &seqExpr{
+							pos: position{line: 122, col: 9, offset: 2830},
+							exprs: []interface{}{
+								&litMatcher{
+									pos:        position{line: 122, col: 9, offset: 2830},
+									val:        "true",
+									ignoreCase: false,
+								},
+								&andExpr{
+									pos: position{line: 122, col: 16, offset: 2837},
+									expr: &ruleRefExpr{
+										pos:  position{line: 122, col: 17, offset: 2838},
+										name: "WordEnd",
+									},
+								},
+							},
 						},
 					},
 					&actionExpr{
-						pos: position{line: 89, col: 39, offset: 1808},
-						run: (*parser).callonBool4,
-						expr: &litMatcher{
-							pos:        position{line: 89, col: 39, offset: 1808},
-							val:        "false",
-							ignoreCase: false,
+						pos: position{line: 122, col: 48, offset: 2869},
+						run: (*parser).callonBool7,
+						expr: &seqExpr{
+							pos: position{line: 122, col: 48, offset: 2869},
+							exprs: []interface{}{
+								&litMatcher{
+									pos:        position{line: 122, col: 48, offset: 2869},
+									val:        "false",
+									ignoreCase: false,
+								},
+								&andExpr{
+									pos: position{line: 122, col: 56, offset: 2877},
+									expr: &ruleRefExpr{
+										pos:  position{line: 122, col: 57, offset: 2878},
+										name: "WordEnd",
+									},
+								},
+							},
 						},
 					},
 				},
 			},
 		},
-		{
-			name: "Integer",
-			pos:  position{line: 91, col: 1, offset: 1839},
-			expr: &actionExpr{
-				pos: position{line: 91, col: 12, offset: 1850},
-				run: (*parser).callonInteger1,
-				expr: &oneOrMoreExpr{
-					pos: position{line: 91, col: 12, offset: 1850},
-					expr: &charClassMatcher{
-						pos:        position{line: 91, col: 12, offset: 1850},
-						val:        "[0-9]",
-						ranges:     []rune{'0', '9'},
-						ignoreCase: false,
-						inverted:   false,
-					},
-				},
-			},
-		},
 		{
 			name: "Numeric",
-			pos:  position{line: 95, col: 1, offset: 1902},
+			pos:  position{line: 125, col: 1, offset: 3016},
 			expr: &actionExpr{
-				pos: position{line: 95, col: 12, offset: 1913},
+				pos: position{line: 125, col: 12, offset: 3027},
 				run: (*parser).callonNumeric1,
-				expr: &labeledExpr{
-					pos:   position{line: 95, col: 12, offset: 1913},
-					label: "num",
-					expr: &choiceExpr{
-						pos: position{line: 95, col: 17, offset: 1918},
-						alternatives: []interface{}{
-							&ruleRefExpr{
-								pos:  position{line: 95, col: 17, offset: 1918},
-								name: "CombinedNum",
-							},
-							&ruleRefExpr{
-								pos:  position{line: 95, col: 31, offset: 1932},
-								name: "RelNum",
-							},
-							&ruleRefExpr{
-								pos:  position{line: 95, col: 40, offset: 1941},
-								name: "AbsNum",
-							},
-						},
-					},
-				},
-			},
-		},
-		{
-			name: "AbsNum",
-			pos:  position{line: 99, col: 1, offset: 1974},
-			expr: &actionExpr{
-				pos: position{line: 99, col: 11, offset: 1984},
-				run: (*parser).callonAbsNum1,
-				expr: &labeledExpr{
-					pos:   position{line: 99, col: 11, offset: 1984},
-					label: "i",
-					expr: &ruleRefExpr{
-						pos:  position{line: 99, col: 13, offset: 1986},
-						name: "Integer",
-					},
-				},
-			},
-		},
-		{
-			name: "RelNum",
-			pos:  position{line: 103, col: 1, offset: 2032},
-			expr: &actionExpr{
-				pos: position{line: 103, col: 11, offset: 2042},
-				run: (*parser).callonRelNum1,
 				expr: &seqExpr{
-					pos: position{line: 103, col: 11, offset: 2042},
+					pos: position{line: 125, col: 12, offset: 3027},
 					exprs: []interface{}{
-						&labeledExpr{
-							pos:   position{line: 103, col: 11, offset: 2042},
-							label: "i",
+						&oneOrMoreExpr{
+							pos: position{line: 125, col: 12, offset: 3027},
 							expr: &ruleRefExpr{
-								pos:  position{line: 103, col: 13, offset: 2044},
-								name: "Integer",
+								pos:  position{line: 125, col: 12, offset: 3027},
+								name: "LengthTerm",
 							},
 						},
-						&litMatcher{
-							pos:        position{line: 103, col: 21, offset: 2052},
-							val:        "%",
-							ignoreCase: false,
+						&andExpr{
+							pos: position{line: 125, col: 24, offset: 3039},
+							expr: &ruleRefExpr{
+								pos:  position{line: 125, col: 25, offset: 3040},
+								name: "WordEnd",
+							},
 						},
 					},
 				},
 			},
 		},
 		{
-			name: "CombinedNum",
-			pos:  position{line: 107, col: 1, offset: 2093},
-			expr: &actionExpr{
-				pos: position{line: 107, col: 16, offset: 2108},
-				run: (*parser).callonCombinedNum1,
-				expr: &seqExpr{
-					pos: position{line: 107, col: 16, offset: 2108},
-					exprs: []interface{}{
-						&labeledExpr{
-							pos:   position{line: 107, col: 16, offset: 2108},
-							label: "rel",
-							expr: &ruleRefExpr{
-								pos:  position{line: 107, col: 20, offset: 2112},
-								name: "RelNum",
-							},
+			name: "LengthTerm",
+			pos:  position{line: 129, col: 1, offset: 3092},
+			expr: &seqExpr{
+				pos: position{line: 129, col: 15, offset: 3106},
+				exprs: []interface{}{
+					&zeroOrMoreExpr{
+						pos: position{line: 129, col: 15, offset: 3106},
+						expr: &charClassMatcher{
+							pos:        position{line: 129, col: 15, offset: 3106},
+							val:        "[+-]",
+							chars:      []rune{'+', '-'},
+							ignoreCase: false,
+							inverted:   false,
 						},
-						&labeledExpr{
-							pos:   position{line: 107, col: 27, offset: 2119},
-							label: "op",
-							expr: &ruleRefExpr{
-								pos:  position{line: 107, col: 30, offset: 2122},
-								name: "CombinedNumOp",
+					},
+					&choiceExpr{
+						pos: position{line: 129, col: 22, offset: 3113},
+						alternatives: []interface{}{
+							&seqExpr{
+								pos: position{line: 129, col: 22, offset: 3113},
+								exprs: []interface{}{
+									&litMatcher{
+										pos:        position{line: 129, col: 22, offset: 3113},
+										val:        "0",
+										ignoreCase: false,
+									},
+									&charClassMatcher{
+										pos:        position{line: 129, col: 26, offset: 3117},
+										val:        "[xX]",
+										chars:      []rune{'x', 'X'},
+										ignoreCase: false,
+										inverted:   false,
+									},
+									&oneOrMoreExpr{
+										pos: position{line: 129, col: 31, offset: 3122},
+										expr: &charClassMatcher{
+											pos:        position{line: 129, col: 31, offset: 3122},
+											val:        "[0-9a-fA-F]",
+											ranges:     []rune{'0', '9', 'a', 'f', 'A', 'F'},
+											ignoreCase: false,
+											inverted:   false,
+										},
+									},
+								},
 							},
-						},
-						&labeledExpr{
-							pos:   position{line: 107, col: 44, offset: 2136},
-							label: "abs",
-							expr: &ruleRefExpr{
-								pos:  position{line: 107, col: 48, offset: 2140},
-								name: "AbsNum",
+							&oneOrMoreExpr{
+								pos: position{line: 129, col: 46, offset: 3137},
+								expr: &charClassMatcher{
+									pos:        position{line: 129, col: 46, offset: 3137},
+									val:        "[0-9]",
+									ranges:     []rune{'0', '9'},
+									ignoreCase: false,
+									inverted:   false,
+								},
 							},
 						},
 					},
-				},
-			},
-		},
-		{
-			name: "CombinedNumOp",
-			pos:  position{line: 115, col: 1, offset: 2283},
-			expr: &actionExpr{
-				pos: position{line: 115, col: 18, offset: 2300},
-				run: (*parser).callonCombinedNumOp1,
-				expr: &choiceExpr{
-					pos: position{line: 115, col: 19, offset: 2301},
-					alternatives: []interface{}{
-						&litMatcher{
-							pos:        position{line: 115, col: 19, offset: 2301},
-							val:        "+",
-							ignoreCase: false,
-						},
-						&litMatcher{
-							pos:        position{line: 115, col: 25, offset: 2307},
-							val:        "-",
+					&zeroOrOneExpr{
+						pos: position{line: 129, col: 54, offset: 3145},
+						expr: &litMatcher{
+							pos:        position{line: 129, col: 54, offset: 3145},
+							val:        "%",
 							ignoreCase: false,
 						},
 					},
@@ -591,38 +615,48 @@ var g = &grammar{
 		},
 		{
 			name: "String",
-			pos:  position{line: 125, col: 1, offset: 2462},
+			pos:  position{line: 131, col: 1, offset: 3151},
 			expr: &actionExpr{
-				pos: position{line: 125, col: 11, offset: 2472},
+				pos: position{line: 131, col: 11, offset: 3161},
 				run: (*parser).callonString1,
 				expr: &seqExpr{
-					pos: position{line: 125, col: 11, offset: 2472},
+					pos: position{line: 131, col: 11, offset: 3161},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 125, col: 11, offset: 2472},
+							pos:        position{line: 131, col: 11, offset: 3161},
 							val:        "\"",
 							ignoreCase: false,
 						},
 						&zeroOrMoreExpr{
-							pos: position{line: 125, col: 15, offset: 2476},
-							expr: &seqExpr{
-								pos: position{line: 125, col: 16, offset: 2477},
-								exprs: []interface{}{
-									&notExpr{
-										pos: position{line: 125, col: 16, offset: 2477},
-										expr: &ruleRefExpr{
-											pos:  position{line: 125, col: 17, offset: 2478},
-											name: "EscapedChar",
+							pos: position{line: 131, col: 15, offset: 3165},
+							expr: &choiceExpr{
+								pos: position{line: 131, col: 17, offset: 3167},
+								alternatives: []interface{}{
+									&seqExpr{
+										pos: position{line: 131, col: 17, offset: 3167},
+										exprs: []interface{}{
+											&litMatcher{
+												pos:        position{line: 131, col: 17, offset: 3167},
+												val:        "\\",
+												ignoreCase: false,
+											},
+											&anyMatcher{
+												line: 131, col: 22, offset: 3172,
+											},
 										},
 									},
-									&anyMatcher{
-										line: 125, col: 29, offset: 2490,
+									&charClassMatcher{
+										pos:        position{line: 131, col: 26, offset: 3176},
+										val:        "[^\"\\\\]",
+										chars:      []rune{'"', '\\'},
+										ignoreCase: false,
+										inverted:   true,
 									},
 								},
 							},
 						},
 						&litMatcher{
-							pos:        position{line: 125, col: 33, offset: 2494},
+							pos:        position{line: 131, col: 36, offset: 3186},
 							val:        "\"",
 							ignoreCase: false,
 						},
@@ -631,25 +665,80 @@ var g = &grammar{
 			},
 		},
 		{
-			name: "EscapedChar",
-			pos:  position{line: 129, col: 1, offset: 2546},
+			name: "Word",
+			pos:  position{line: 136, col: 1, offset: 3333},
+			expr: &actionExpr{
+				pos: position{line: 136, col: 9, offset: 3341},
+				run: (*parser).callonWord1,
+				expr: &choiceExpr{
+					pos: position{line: 136, col: 11, offset: 3343},
+					alternatives: []interface{}{
+						&seqExpr{
+							pos: position{line: 136, col: 11, offset: 3343},
+							exprs: []interface{}{
+								&litMatcher{
+									pos:        position{line: 136, col: 11, offset: 3343},
+									val:        "(",
+									ignoreCase: false,
+								},
+								&zeroOrMoreExpr{
+									pos: position{line: 136, col: 15, offset: 3347},
+									expr: &charClassMatcher{
+										pos:        position{line: 136, col: 15, offset: 3347},
+										val:        "[^)]",
+										chars:      []rune{')'},
+										ignoreCase: false,
+										inverted:   true,
+									},
+								},
+								&litMatcher{
+									pos:        position{line: 136, col: 21, offset: 3353},
+									val:        ")",
+									ignoreCase: false,
+								},
+							},
+						},
+						&oneOrMoreExpr{
+							pos: position{line: 136, col: 27, offset: 3359},
+							expr: &ruleRefExpr{
+								pos:  position{line: 136, col: 27, offset: 3359},
+								name: "WordChar",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "WordChar",
+			pos:  position{line: 140, col: 1, offset: 3407},
 			expr: &charClassMatcher{
-				pos:        position{line: 129, col: 15, offset: 2562},
-				val:        "[\\x00-\\x1f\"\\\\]",
-				chars:      []rune{'"', '\\'},
-				ranges:     []rune{'\x00', '\x1f'},
+				pos:        position{line: 140, col: 13, offset: 3419},
+				val:        "[^ \\t\\r\\n{}\"]",
+				chars:      []rune{' ', '\t', '\r', '\n', '{', '}', '"'},
 				ignoreCase: false,
-				inverted:   false,
+				inverted:   true,
+			},
+		},
+		{
+			name: "WordEnd",
+			pos:  position{line: 142, col: 1, offset: 3434},
+			expr: &notExpr{
+				pos: position{line: 142, col: 12, offset: 3445},
+				expr: &ruleRefExpr{
+					pos:  position{line: 142, col: 13, offset: 3446},
+					name: "WordChar",
+				},
 			},
 		},
 		{
 			name:        "_",
 			displayName: "\"whitespace\"",
-			pos:         position{line: 131, col: 1, offset: 2578},
+			pos:         position{line: 144, col: 1, offset: 3456},
 			expr: &zeroOrMoreExpr{
-				pos: position{line: 131, col: 19, offset: 2596},
+				pos: position{line: 144, col: 19, offset: 3474},
 				expr: &charClassMatcher{
-					pos:        position{line: 131, col: 19, offset: 2596},
+					pos:        position{line: 144, col: 19, offset: 3474},
 					val:        "[ \\n\\t\\r]",
 					chars:      []rune{' ', '\n', '\t', '\r'},
 					ignoreCase: false,
@@ -659,11 +748,11 @@ var g = &grammar{
 		},
 		{
 			name: "EOF",
-			pos:  position{line: 133, col: 1, offset: 2608},
+			pos:  position{line: 146, col: 1, offset: 3486},
 			expr: &notExpr{
-				pos: position{line: 133, col: 8, offset: 2615},
+				pos: position{line: 146, col: 8, offset: 3493},
 				expr: &anyMatcher{
-					line: 133, col: 9, offset: 2616,
+					line: 146, col: 9, offset: 3494,
 				},
 			},
 		},
@@ -674,6 +763,7 @@ func (c *current) onTheme1(statements interface{}) (interface{}, error) {
 	theme := &Theme{}
 	ss := toIfaceSlice(statements)
 	for _, s := range ss {
+		theme.Nodes = append(theme.Nodes, s.(Node))
 		switch e := s.(type) {
 		case *Property:
 			theme.Props = append(theme.Props, e)
@@ -701,10 +791,14 @@ func (p *parser) callonStatement1() (interface{}, error) {
 }
 
 func (c *current) onGPD1(option, val interface{}) (interface{}, error) {
-	log.Printf("get GPD: %q\n", string(c.text))
+	debugf("get GPD: %q\n", string(c.text))
+	v := val.(valueNode)
 	return &Property{
-		name:  option.(string),
-		value: val,
+		name:      option.(string),
+		value:     v.value,
+		span:      getSpan(c),
+		valueSpan: v.span,
+		raw:       v.raw,
 	}, nil
 }
 
@@ -715,9 +809,13 @@ func (p *parser) callonGPD1() (interface{}, error) {
 }
 
 func (c *current) onCPD1(option, val interface{}) (interface{}, error) {
+	v := val.(valueNode)
 	return &Property{
-		name:  option.(string),
-		value: val,
+		name:      option.(string),
+		value:     v.value,
+		span:      getSpan(c),
+		valueSpan: v.span,
+		raw:       v.raw,
 	}, nil
 }
 
@@ -730,10 +828,12 @@ func (p *parser) callonCPD1() (interface{}, error) {
 func (c *current) onComponent1(type0, elements interface{}) (interface{}, error) {
 	comp := &Component{
 		Type: type0.(string),
+		span: getSpan(c),
 	}
 	elems := toIfaceSlice(elements)
 	for _, elem := range elems {
-		log.Printf("elem: %#v\n", elem)
+		debugf("elem: %#v\n", elem)
+		comp.Nodes = append(comp.Nodes, elem.(Node))
 		switch e := elem.(type) {
 		case *Property:
 			comp.Props = append(comp.Props, e)
@@ -762,8 +862,11 @@ func (p *parser) callonComponentElement1() (interface{}, error) {
 }
 
 func (c *current) onComment1() (interface{}, error) {
-	log.Printf("get comment: %q\n", string(c.text))
-	return nil, nil
+	debugf("get comment: %q\n", string(c.text))
+	return &Comment{
+		Text: strings.TrimSpace(string(c.text)),
+		span: getSpan(c),
+	}, nil
 }
 
 func (p *parser) callonComment1() (interface{}, error) {
@@ -793,7 +896,11 @@ func (p *parser) callonID1() (interface{}, error) {
 }
 
 func (c *current) onValue1(val interface{}) (interface{}, error) {
-	return val, nil
+	return valueNode{
+		value: val,
+		span:  getSpan(c),
+		raw:   string(c.text),
+	}, nil
 }
 
 func (p *parser) callonValue1() (interface{}, error) {
@@ -812,96 +919,45 @@ func (p *parser) callonBool2() (interface{}, error) {
 	return p.cur.onBool2()
 }
 
-func (c *current) onBool4() (interface{}, error) {
+func (c *current) onBool7() (interface{}, error) {
 	return false, nil
 }
 
-func (p *parser) callonBool4() (interface{}, error) {
-	stack := p.vstack[len(p.vstack)-1]
-	_ = stack
-	return p.cur.onBool4()
-}
-
-func (c *current) onInteger1() (interface{}, error) {
-	return strconv.Atoi(string(c.text))
-}
-
-func (p *parser) callonInteger1() (interface{}, error) {
+func (p *parser) callonBool7() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onInteger1()
+	return p.cur.onBool7()
 }
 
-func (c *current) onNumeric1(num interface{}) (interface{}, error) {
-	return num, nil
+func (c *current) onNumeric1() (interface{}, error) {
+	return ParseLength(string(c.text))
 }
 
 func (p *parser) callonNumeric1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNumeric1(stack["num"])
-}
-
-func (c *current) onAbsNum1(i interface{}) (interface{}, error) {
-	return AbsNum(i.(int)), nil
-}
-
-func (p *parser) callonAbsNum1() (interface{}, error) {
-	stack := p.vstack[len(p.vstack)-1]
-	_ = stack
-	return p.cur.onAbsNum1(stack["i"])
+	return p.cur.onNumeric1()
 }
 
-func (c *current) onRelNum1(i interface{}) (interface{}, error) {
-	return RelNum(i.(int)), nil
-}
-
-func (p *parser) callonRelNum1() (interface{}, error) {
-	stack := p.vstack[len(p.vstack)-1]
-	_ = stack
-	return p.cur.onRelNum1(stack["i"])
-}
-
-func (c *current) onCombinedNum1(rel, op, abs interface{}) (interface{}, error) {
-	return CombinedNum{
-		Rel: int(rel.(RelNum)),
-		Abs: int(abs.(AbsNum)),
-		Op:  op.(CombinedNumOp),
-	}, nil
-}
-
-func (p *parser) callonCombinedNum1() (interface{}, error) {
-	stack := p.vstack[len(p.vstack)-1]
-	_ = stack
-	return p.cur.onCombinedNum1(stack["rel"], stack["op"], stack["abs"])
-}
-
-func (c *current) onCombinedNumOp1() (interface{}, error) {
-	switch c.text[0] {
-	case '+':
-		return CombinedNumAdd, nil
-	case '-':
-		return CombinedNumSub, nil
-	}
-	return 0, nil
+func (c *current) onString1() (interface{}, error) {
+	return unquoteString(c.text), nil
 }
 
-func (p *parser) callonCombinedNumOp1() (interface{}, error) {
+func (p *parser) callonString1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onCombinedNumOp1()
+	return p.cur.onString1()
 }
 
-func (c *current) onString1() (interface{}, error) {
-	return strconv.Unquote(string(c.text))
+func (c *current) onWord1() (interface{}, error) {
+	return string(c.text), nil
 }
 
-func (p *parser) callonString1() (interface{}, error) {
+func (p *parser) callonWord1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onString1()
+	return p.cur.onWord1()
 }
-
 var (
 	// errNoRule is returned when the grammar to parse has no rule.
 	errNoRule = errors.New("grammar has no rule")
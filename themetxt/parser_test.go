@@ -0,0 +1,141 @@
+package themetxt
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update .golden files in testdata")
+
+// describeTheme 按源文件中的顺序列出主题的属性、组件和注释，
+// 属性包括值的类型、值和 grub 看到的字符串
+func describeTheme(t *Theme) string {
+	var buf bytes.Buffer
+	describeNodes(&buf, t.Nodes, "")
+	return buf.String()
+}
+
+func describeNodes(buf *bytes.Buffer, nodes []Node, indent string) {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *Comment:
+			fmt.Fprintf(buf, "%scomment %q\n", indent, n.Text)
+		case *Property:
+			fmt.Fprintf(buf, "%s%s = %T %#v %q\n", indent, n.name, n.value, n.value, n.GrubString())
+		case *Component:
+			fmt.Fprintf(buf, "%s+ %s\n", indent, n.Type)
+			describeNodes(buf, n.Nodes, indent+"    ")
+		}
+	}
+}
+
+// TestParseCorpus 解析 testdata 中的主题，和 .golden 文件比较解析结果，
+// 并检查没有修改的主题输出后和源文件相同
+func TestParseCorpus(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no theme in testdata")
+	}
+
+	for _, file := range files {
+		file := file
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			theme, err := ParseThemeFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := describeTheme(theme)
+			golden := strings.TrimSuffix(file, ".txt") + ".golden"
+			if *update {
+				err = ioutil.WriteFile(golden, []byte(got), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("parse result differs from %s:\ngot:\n%s\nwant:\n%s", golden, got, want)
+			}
+
+			var out bytes.Buffer
+			theme.WriteTo(&out)
+			if !bytes.Equal(out.Bytes(), theme.source) {
+				t.Errorf("WriteTo changed the unmodified theme:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		src  string
+		line int
+		col  int
+	}{
+		{"title-text: \"x\"\n+ label {\n", 3, 1},
+		{"title-text \"x\"\n", 1, 12},
+		{"+ label { left = 10 \n  top = { }\n", 2, 9},
+	}
+	for _, test := range tests {
+		_, err := ParseTheme("test.txt", []byte(test.src))
+		pe, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("%q: expected *ParseError, got %v", test.src, err)
+			continue
+		}
+		if pe.Line != test.line || pe.Col != test.col {
+			t.Errorf("%q: expected error at %d:%d, got %d:%d", test.src,
+				test.line, test.col, pe.Line, pe.Col)
+		}
+	}
+}
+
+// TestParseLength 通过语法解析长度，检查得到的绝对部分和相对部分
+func TestParseLength(t *testing.T) {
+	tests := []struct {
+		str string
+		abs int
+		rel int
+	}{
+		{"50", 50, 0},
+		{"-10", -10, 0},
+		{"+5", 5, 0},
+		{"50%", 0, 50},
+		{"50%-20", -20, 50},
+		{"50%+-5", -5, 50},
+		{"10+50%-5+10%", 5, 60},
+		{"0x10", 16, 0},
+		{"010", 8, 0},
+		{"--5", 5, 0},
+		{`"25%+10"`, 10, 25},
+	}
+	for _, test := range tests {
+		src := "+ label { left = " + test.str + " }\n"
+		theme, err := ParseTheme("test.txt", []byte(src))
+		if err != nil {
+			t.Errorf("%s: %v", test.str, err)
+			continue
+		}
+		l, ok := theme.Components[0].GetPropLength("left")
+		if !ok {
+			t.Errorf("%s: left is not a length", test.str)
+			continue
+		}
+		abs, rel := GetLengthParts(l)
+		if abs != test.abs || rel != test.rel {
+			t.Errorf("%s: expected %d%%%+d, got %d%%%+d", test.str, test.rel, test.abs, rel, abs)
+		}
+	}
+}
@@ -0,0 +1,426 @@
+package themetxt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PropType 是属性值的类型
+type PropType int
+
+const (
+	PropTypeString PropType = iota
+	// 颜色，比如 "#ffffff" 或 "white"
+	PropTypeColor
+	// 字体名，比如 "DejaVu Sans Regular 16"
+	PropTypeFont
+	PropTypeBool
+	// 整数，比如 item_height
+	PropTypeNumber
+	// 长度，可以是 10、50% 或 50%-10
+	PropTypeLength
+	// 角度，可以是 grub 的角度单位，也可以是 "90 deg"
+	PropTypeAngle
+)
+
+func (t PropType) String() string {
+	switch t {
+	case PropTypeString:
+		return "string"
+	case PropTypeColor:
+		return "color"
+	case PropTypeFont:
+		return "font"
+	case PropTypeBool:
+		return "bool"
+	case PropTypeNumber:
+		return "number"
+	case PropTypeLength:
+		return "length"
+	case PropTypeAngle:
+		return "angle"
+	default:
+		return fmt.Sprintf("PropType(%d)", int(t))
+	}
+}
+
+// PropSpec 描述一个属性
+type PropSpec struct {
+	Name string
+	Type PropType
+	// grub 中的默认值，nil 表示没有默认值
+	Default interface{}
+	// 不为空时属性值只能是其中之一
+	Choices []string
+}
+
+// ComponentSpec 描述一种组件
+type ComponentSpec struct {
+	Type string
+	// 是否可以包含子组件
+	Container bool
+	Props     []*PropSpec
+}
+
+// GetProp 返回组件的属性，没有这个属性时返回 nil
+func (cs *ComponentSpec) GetProp(name string) *PropSpec {
+	return findPropSpec(cs.Props, name)
+}
+
+func findPropSpec(specs []*PropSpec, name string) *PropSpec {
+	for _, spec := range specs {
+		if spec.Name == name {
+			return spec
+		}
+	}
+	return nil
+}
+
+// 所有组件都有的属性，参考 grub 的 theme_loader.c
+var commonPropSpecs = []*PropSpec{
+	{Name: "left", Type: PropTypeLength, Default: AbsNum(0)},
+	{Name: "top", Type: PropTypeLength, Default: AbsNum(0)},
+	{Name: "width", Type: PropTypeLength, Default: AbsNum(0)},
+	{Name: "height", Type: PropTypeLength, Default: AbsNum(0)},
+	{Name: "id", Type: PropTypeString},
+}
+
+// GlobalPropSpecs 是全局属性，参考 grub 的 theme_set_string
+var GlobalPropSpecs = []*PropSpec{
+	{Name: "title-text", Type: PropTypeString, Default: "GRUB Boot Menu"},
+	{Name: "title-font", Type: PropTypeFont, Default: "Unknown Regular 16"},
+	{Name: "title-color", Type: PropTypeColor, Default: "0,0,0"},
+	{Name: "message-font", Type: PropTypeFont},
+	{Name: "message-color", Type: PropTypeColor},
+	{Name: "message-bg-color", Type: PropTypeColor},
+	// grub 读到 desktop-image 时就按当时的缩放方式和对齐方式缩放图片，
+	// 这三个属性要写在 desktop-image 前面，fmt 按这里的顺序排列属性
+	{Name: "desktop-image-scale-method", Type: PropTypeString, Default: "stretch",
+		Choices: []string{"stretch", "crop", "padding", "fitwidth", "fitheight"}},
+	{Name: "desktop-image-h-align", Type: PropTypeString, Default: "center",
+		Choices: []string{"left", "center", "right"}},
+	{Name: "desktop-image-v-align", Type: PropTypeString, Default: "center",
+		Choices: []string{"top", "center", "bottom"}},
+	{Name: "desktop-image", Type: PropTypeString},
+	// 和 grub 的 default_bg_color 一致
+	{Name: "desktop-color", Type: PropTypeColor, Default: "#ffffff"},
+	{Name: "terminal-box", Type: PropTypeString},
+	{Name: "terminal-font", Type: PropTypeFont, Default: "Fixed 10"},
+	{Name: "terminal-border", Type: PropTypeNumber, Default: AbsNum(3)},
+	// 终端位置的默认值和 grub_gfxmenu_view_new 一致
+	{Name: "terminal-left", Type: PropTypeLength, Default: RelNum(15)},
+	{Name: "terminal-top", Type: PropTypeLength, Default: RelNum(15)},
+	{Name: "terminal-width", Type: PropTypeLength, Default: RelNum(70)},
+	{Name: "terminal-height", Type: PropTypeLength, Default: RelNum(70)},
+}
+
+// ComponentSpecs 是各种组件的属性，参考 grub 的 gui_*.c 中的 set_property
+var ComponentSpecs = map[string]*ComponentSpec{
+	ComponentTypeBootMenu: {
+		Type: ComponentTypeBootMenu,
+		Props: []*PropSpec{
+			{Name: "item_font", Type: PropTypeFont, Default: "Unknown Regular 16"},
+			{Name: "selected_item_font", Type: PropTypeFont, Default: "inherit"},
+			{Name: "item_color", Type: PropTypeColor, Default: "black"},
+			{Name: "selected_item_color", Type: PropTypeColor, Default: "inherit"},
+			{Name: "icon_width", Type: PropTypeNumber, Default: AbsNum(32)},
+			{Name: "icon_height", Type: PropTypeNumber, Default: AbsNum(32)},
+			{Name: "item_height", Type: PropTypeNumber, Default: AbsNum(42)},
+			{Name: "item_padding", Type: PropTypeNumber, Default: AbsNum(14)},
+			{Name: "item_icon_space", Type: PropTypeNumber, Default: AbsNum(4)},
+			{Name: "item_spacing", Type: PropTypeNumber, Default: AbsNum(16)},
+			{Name: "visible", Type: PropTypeBool, Default: true},
+			{Name: "menu_pixmap_style", Type: PropTypeString},
+			{Name: "item_pixmap_style", Type: PropTypeString},
+			{Name: "selected_item_pixmap_style", Type: PropTypeString},
+			{Name: "scrollbar", Type: PropTypeBool, Default: true},
+			{Name: "scrollbar_frame", Type: PropTypeString},
+			{Name: "scrollbar_thumb", Type: PropTypeString},
+			{Name: "scrollbar_thumb_overlay", Type: PropTypeBool, Default: false},
+			{Name: "scrollbar_width", Type: PropTypeNumber, Default: AbsNum(16)},
+			{Name: "scrollbar_slice", Type: PropTypeString, Default: "east",
+				Choices: []string{"west", "center", "east"}},
+			{Name: "scrollbar_left_pad", Type: PropTypeNumber, Default: AbsNum(0)},
+			{Name: "scrollbar_right_pad", Type: PropTypeNumber, Default: AbsNum(0)},
+			{Name: "scrollbar_top_pad", Type: PropTypeNumber, Default: AbsNum(0)},
+			{Name: "scrollbar_bottom_pad", Type: PropTypeNumber, Default: AbsNum(0)},
+			{Name: "max_items_shown", Type: PropTypeNumber},
+			{Name: "theme_dir", Type: PropTypeString},
+		},
+	},
+	ComponentTypeProgressBar: {
+		Type: ComponentTypeProgressBar,
+		Props: []*PropSpec{
+			{Name: "text", Type: PropTypeString, Default: ""},
+			{Name: "font", Type: PropTypeFont, Default: "Unknown Regular 16"},
+			{Name: "text_color", Type: PropTypeColor, Default: "black"},
+			{Name: "border_color", Type: PropTypeColor, Default: "black"},
+			{Name: "bg_color", Type: PropTypeColor, Default: "128,128,128"},
+			{Name: "fg_color", Type: PropTypeColor, Default: "200,200,200"},
+			{Name: "bar_style", Type: PropTypeString},
+			{Name: "highlight_style", Type: PropTypeString},
+			{Name: "highlight_overlay", Type: PropTypeBool, Default: false},
+			{Name: "visible", Type: PropTypeBool, Default: true},
+			{Name: "theme_dir", Type: PropTypeString},
+		},
+	},
+	ComponentTypeCircularProgress: {
+		Type: ComponentTypeCircularProgress,
+		Props: []*PropSpec{
+			{Name: "num_ticks", Type: PropTypeNumber, Default: AbsNum(64)},
+			{Name: "start_angle", Type: PropTypeAngle, Default: AbsNum(-64)},
+			{Name: "ticks_disappear", Type: PropTypeBool, Default: false},
+			{Name: "center_bitmap", Type: PropTypeString},
+			{Name: "tick_bitmap", Type: PropTypeString},
+			{Name: "visible", Type: PropTypeBool, Default: true},
+			{Name: "theme_dir", Type: PropTypeString},
+		},
+	},
+	ComponentTypeLabel: {
+		Type: ComponentTypeLabel,
+		Props: []*PropSpec{
+			{Name: "text", Type: PropTypeString, Default: ""},
+			{Name: "font", Type: PropTypeFont, Default: "Unknown Regular 16"},
+			{Name: "color", Type: PropTypeColor, Default: "black"},
+			{Name: "align", Type: PropTypeString, Default: "left",
+				Choices: []string{"left", "center", "right"}},
+			{Name: "visible", Type: PropTypeBool, Default: true},
+		},
+	},
+	ComponentTypeImage: {
+		Type: ComponentTypeImage,
+		Props: []*PropSpec{
+			{Name: "file", Type: PropTypeString},
+			{Name: "theme_dir", Type: PropTypeString},
+		},
+	},
+	ComponentTypeHBox: {
+		Type:      ComponentTypeHBox,
+		Container: true,
+	},
+	ComponentTypeVBox: {
+		Type:      ComponentTypeVBox,
+		Container: true,
+	},
+	ComponentTypeCanvas: {
+		Type:      ComponentTypeCanvas,
+		Container: true,
+	},
+}
+
+// GetPropSpec 返回属性的描述，compType 为空时查找全局属性，找不到时返回 nil
+func GetPropSpec(compType, name string) *PropSpec {
+	if compType == "" {
+		return findPropSpec(GlobalPropSpecs, name)
+	}
+	if spec := findPropSpec(commonPropSpecs, name); spec != nil {
+		return spec
+	}
+	cs, ok := ComponentSpecs[compType]
+	if !ok {
+		return nil
+	}
+	return cs.GetProp(name)
+}
+
+// getDefault 返回属性描述中的默认值，没有默认值时返回 nil
+func getDefault(compType, name string) interface{} {
+	if spec := GetPropSpec(compType, name); spec != nil {
+		return spec.Default
+	}
+	return nil
+}
+
+// DiagnosticKind 是检查出的问题的种类
+type DiagnosticKind int
+
+const (
+	DiagUnknownProp DiagnosticKind = iota
+	DiagWrongType
+	DiagInvalidValue
+	DiagDuplicateProp
+	DiagUnknownComponent
+	DiagNotContainer
+)
+
+func (k DiagnosticKind) String() string {
+	switch k {
+	case DiagUnknownProp:
+		return "unknown-property"
+	case DiagWrongType:
+		return "wrong-type"
+	case DiagInvalidValue:
+		return "invalid-value"
+	case DiagDuplicateProp:
+		return "duplicate-property"
+	case DiagUnknownComponent:
+		return "unknown-component"
+	case DiagNotContainer:
+		return "not-container"
+	default:
+		return fmt.Sprintf("DiagnosticKind(%d)", int(k))
+	}
+}
+
+// Diagnostic 是 Validate 检查出的一个问题，Line 和 Col 从 1 开始，
+// 不是从源文件解析得到的主题没有位置，Line 和 Col 为 0。
+type Diagnostic struct {
+	Kind      DiagnosticKind
+	Filename  string
+	Line      int
+	Col       int
+	Component string
+	Prop      string
+	Msg       string
+}
+
+func (d *Diagnostic) String() string {
+	var where string
+	if d.Component == "" {
+		where = "global"
+	} else {
+		where = d.Component
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s [%s]", d.Filename, d.Line, d.Col, where, d.Msg, d.Kind)
+}
+
+// validator 检查主题，记录检查出的问题
+type validator struct {
+	theme *Theme
+	diags []*Diagnostic
+}
+
+func (v *validator) add(kind DiagnosticKind, s span, compType, prop, format string,
+	args ...interface{}) {
+	d := &Diagnostic{
+		Kind:      kind,
+		Filename:  v.theme.filename,
+		Component: compType,
+		Prop:      prop,
+		Msg:       fmt.Sprintf(format, args...),
+	}
+	if s.hasSource() {
+		d.Line, d.Col = v.theme.getPosition(s.start)
+	}
+	v.diags = append(v.diags, d)
+}
+
+// checkValueType 按照 grub 解释属性值的方式检查属性值是否符合描述，
+// grub 可以接受但多半是写错了的值也算作类型错误，比如 item_height = 50%
+func checkValueType(spec *PropSpec, prop *Property) bool {
+	str := prop.GrubString()
+	switch spec.Type {
+	case PropTypeString, PropTypeColor, PropTypeFont:
+		return true
+	case PropTypeBool:
+		return str == "true" || str == "false"
+	case PropTypeNumber:
+		_, complete := parseGrubInt(str)
+		return complete
+	case PropTypeLength:
+		_, _, err := parseProportionalSpec(str)
+		return err == nil
+	case PropTypeAngle:
+		str = strings.TrimLeft(strings.TrimSpace(str), "+-")
+		_, rest, ok := scanUint(str, 10)
+		rest = strings.TrimSpace(rest)
+		return ok && (rest == "" || rest == "deg" || rest == "°")
+	}
+	return false
+}
+
+func (v *validator) checkProps(compType string, props []*Property) {
+	seen := make(map[string]*Property)
+	for _, prop := range props {
+		// 以 _ 开头的属性是模板使用的，不会输出
+		if strings.HasPrefix(prop.name, "_") {
+			continue
+		}
+
+		if _, ok := seen[prop.name]; ok {
+			v.add(DiagDuplicateProp, prop.span, compType, prop.name,
+				"property %q is set more than once, the last one wins", prop.name)
+		}
+		seen[prop.name] = prop
+
+		spec := GetPropSpec(compType, prop.name)
+		if spec == nil {
+			if compType == "" || ComponentSpecs[compType] != nil {
+				v.add(DiagUnknownProp, prop.span, compType, prop.name,
+					"unknown property %q", prop.name)
+			}
+			continue
+		}
+		if !checkValueType(spec, prop) {
+			v.add(DiagWrongType, prop.span, compType, prop.name,
+				"property %q expects %s, got %s", prop.name, spec.Type, prop.Raw())
+			continue
+		}
+		if len(spec.Choices) > 0 {
+			str := prop.GrubString()
+			if !containsString(spec.Choices, str) {
+				v.add(DiagInvalidValue, prop.span, compType, prop.name,
+					"property %q must be one of %s, got %q", prop.name,
+					strings.Join(spec.Choices, ", "), str)
+			}
+		}
+		// selected_item_color 的默认值 inherit 不是颜色。字体找不到时 grub 使用其他字体，
+		// 颜色无法解析时 grub 加载主题失败。
+		if spec.Type == PropTypeColor && prop.GrubString() != spec.Default {
+			if _, err := ParseColor(prop.GrubString()); err != nil {
+				v.add(DiagInvalidValue, prop.span, compType, prop.name,
+					"property %q: %v", prop.name, err)
+			}
+		}
+	}
+}
+
+func (v *validator) checkComponent(c *Component) {
+	cs, ok := ComponentSpecs[c.Type]
+	if !ok {
+		var types []string
+		for t := range ComponentSpecs {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		v.add(DiagUnknownComponent, c.span, c.Type, "",
+			"unknown component type %q, expected one of %s", c.Type,
+			strings.Join(types, ", "))
+	} else if !cs.Container && len(c.Children) > 0 {
+		v.add(DiagNotContainer, c.Children[0].span, c.Type, "",
+			"component %q can not contain other components", c.Type)
+	}
+
+	v.checkProps(c.Type, c.Props)
+	for _, child := range c.Children {
+		v.checkComponent(child)
+	}
+}
+
+func containsString(list []string, str string) bool {
+	for _, item := range list {
+		if item == str {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate 按照属性的描述检查主题，返回未知的属性和组件、类型错误、
+// 重复的属性等问题，按在文件中的位置排序。
+func (t *Theme) Validate() []*Diagnostic {
+	v := &validator{theme: t}
+	v.checkProps("", t.Props)
+	for _, comp := range t.Components {
+		v.checkComponent(comp)
+	}
+	sort.SliceStable(v.diags, func(i, j int) bool {
+		a, b := v.diags[i], v.diags[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Col < b.Col
+	})
+	return v.diags
+}
@@ -0,0 +1,144 @@
+package themetxt
+
+import (
+	"strings"
+	"testing"
+)
+
+func validateString(t *testing.T, src string) []string {
+	t.Helper()
+	theme, err := ParseTheme("theme.txt", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result []string
+	for _, d := range theme.Validate() {
+		result = append(result, d.String())
+	}
+	return result
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "valid",
+			src: `title-text: "GRUB"
+title-color: "#cccccc"
+desktop-color: "32,32,32"
+terminal-left: 10%
++ boot_menu {
+    left = 10%
+    item_color = "white"
+    selected_item_color = "inherit"
+    item_height = 30
+    scrollbar = false
+    scrollbar_slice = "west"
+}
++ vbox {
+    + label { text = "a" align = "center" }
+}
+`,
+		},
+		{
+			name: "unknown",
+			src: `title-txt: "GRUB"
++ boot_menu { item_colour = "white" }
++ button { }
+`,
+			want: []string{
+				`theme.txt:1:1: global: unknown property "title-txt" [unknown-property]`,
+				`theme.txt:2:15: boot_menu: unknown property "item_colour" [unknown-property]`,
+				`theme.txt:3:1: button: unknown component type "button", expected one of ` +
+					`boot_menu, canvas, circular_progress, hbox, image, label, progress_bar, vbox ` +
+					`[unknown-component]`,
+			},
+		},
+		{
+			name: "types",
+			// grub 把属性值都当作字符串，10 也是字符串
+			src: `title-text: 10
++ boot_menu { item_height = "big" scrollbar = 50% }
+`,
+			want: []string{
+				`theme.txt:2:15: boot_menu: property "item_height" expects number, got "big" [wrong-type]`,
+				`theme.txt:2:35: boot_menu: property "scrollbar" expects bool, got 50% [wrong-type]`,
+			},
+		},
+		{
+			name: "values",
+			src: `desktop-image-scale-method: "zoom"
+desktop-color: "#12345"
++ boot_menu {
+    item_color = "dark"
+    selected_item_color = "1,2"
+}
+`,
+			want: []string{
+				`theme.txt:1:1: global: property "desktop-image-scale-method" must be one of ` +
+					`stretch, crop, padding, fitwidth, fitheight, got "zoom" [invalid-value]`,
+				`theme.txt:2:1: global: property "desktop-color": invalid color specification "#12345" [invalid-value]`,
+				`theme.txt:4:5: boot_menu: property "item_color": invalid named color "dark" [invalid-value]`,
+				`theme.txt:5:5: boot_menu: property "selected_item_color": invalid color specification "1,2" [invalid-value]`,
+			},
+		},
+		{
+			name: "structure",
+			src: `title-text: "a"
+title-text: "b"
++ label {
+    + image { }
+}
+`,
+			want: []string{
+				`theme.txt:2:1: global: property "title-text" is set more than once, the last one wins [duplicate-property]`,
+				`theme.txt:4:5: label: component "label" can not contain other components [not-container]`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := validateString(t, test.src)
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"),
+					strings.Join(test.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestGetPropDefault(t *testing.T) {
+	theme, err := ParseTheme("theme.txt", []byte(`title-font: "Sans 12"
++ boot_menu { item_height = 30 }
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if font, ok := theme.GetPropString("title-font"); font != "Sans 12" || !ok {
+		t.Errorf("title-font: got %q %v", font, ok)
+	}
+	if text, ok := theme.GetPropString("title-text"); text != "GRUB Boot Menu" || ok {
+		t.Errorf("title-text: got %q %v, want the default", text, ok)
+	}
+	if left, ok := theme.GetPropLength("terminal-left"); left != RelNum(15) || ok {
+		t.Errorf("terminal-left: got %v %v, want the default", left, ok)
+	}
+
+	bm := theme.Components[0]
+	if h, ok := bm.GetPropLength("item_height"); h != AbsNum(30) || !ok {
+		t.Errorf("item_height: got %v %v", h, ok)
+	}
+	if sp, ok := bm.GetPropLength("item_spacing"); sp != AbsNum(16) || ok {
+		t.Errorf("item_spacing: got %v %v, want the default", sp, ok)
+	}
+	if sb, ok := bm.GetPropBool("scrollbar"); !sb || ok {
+		t.Errorf("scrollbar: got %v %v, want the default", sb, ok)
+	}
+	if font, ok := bm.GetPropString("selected_item_font"); font != "inherit" || ok {
+		t.Errorf("selected_item_font: got %q %v, want the default", font, ok)
+	}
+}
@@ -0,0 +1,255 @@
+package themetxt
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+var bom = []byte("\ufeff")
+
+// span 是语句在源文件中的位置 [start, end)，单位是字节，不含两边的空白。
+// 不是从源文件解析得到的语句 span 为零值。
+type span struct {
+	start, end int
+}
+
+// Span 返回语句在源文件中的位置
+func (s span) Span() (start, end int) {
+	return s.start, s.end
+}
+
+func (s span) hasSource() bool {
+	return s.end > 0
+}
+
+// Node 是 theme.txt 中的一个语句：*Property、*Component 或 *Comment
+type Node interface {
+	Span() (start, end int)
+}
+
+// Comment 是以 # 开头的注释，Text 包含 #
+type Comment struct {
+	Text string
+	span
+}
+
+// valueNode 是属性值和它在源文件中的位置、原文
+type valueNode struct {
+	value interface{}
+	span  span
+	raw   string
+}
+
+// getSpan 返回当前规则匹配的内容在源文件中的位置，去掉两边的空白
+func getSpan(c *current) span {
+	start := c.pos.offset
+	text := c.text
+	trimmed := bytes.TrimLeft(text, " \t\r\n")
+	start += len(text) - len(trimmed)
+	trimmed = bytes.TrimRight(trimmed, " \t\r\n")
+	return span{start: start, end: start + len(trimmed)}
+}
+
+// getPosition 返回 offset 在源文件中的行号和列号，从 1 开始，列号按字符计算
+func (t *Theme) getPosition(offset int) (line, col int) {
+	before := t.source[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+	col = utf8.RuneCount(bytes.TrimPrefix(before[lineStart:], bom)) + 1
+	return line, col
+}
+
+// Position 返回语句在源文件中的行号和列号，不是从源文件解析得到的语句返回 0, 0
+func (t *Theme) Position(n Node) (line, col int) {
+	start, end := n.Span()
+	if t.source == nil || end == 0 {
+		return 0, 0
+	}
+	return t.getPosition(start)
+}
+
+// edit 把源文件中 [start, end) 的内容替换为 text，start 等于 end 时是插入
+type edit struct {
+	start, end int
+	text       string
+}
+
+// sourceWriter 在源文件的基础上输出主题，只改动修改过的属性和新加的内容，
+// 保留注释、空行和原来的顺序。
+type sourceWriter struct {
+	source []byte
+	edits  []edit
+	// 源文件使用的换行符
+	newline string
+}
+
+// getLineStart 返回 offset 所在行的开始位置，第一行不包括 BOM
+func (sw *sourceWriter) getLineStart(offset int) int {
+	lineStart := bytes.LastIndexByte(sw.source[:offset], '\n') + 1
+	if lineStart == 0 && offset >= len(bom) && bytes.HasPrefix(sw.source, bom) {
+		lineStart = len(bom)
+	}
+	return lineStart
+}
+
+// getIndent 返回 offset 所在行开头的空白
+func (sw *sourceWriter) getIndent(offset int) string {
+	lineStart := sw.getLineStart(offset)
+	line := sw.source[lineStart:offset]
+	return string(line[:len(line)-len(bytes.TrimLeft(line, " \t"))])
+}
+
+// onlySpaceBefore 判断 offset 所在行中 offset 前面是否只有空白
+func (sw *sourceWriter) onlySpaceBefore(offset int) bool {
+	lineStart := sw.getLineStart(offset)
+	return len(bytes.TrimLeft(sw.source[lineStart:offset], " \t")) == 0
+}
+
+// removeStatement 删除一个语句，语句独占一行时删除整行
+func (sw *sourceWriter) removeStatement(s span) {
+	start, end := s.start, s.end
+	if sw.onlySpaceBefore(start) {
+		rest := sw.source[end:]
+		lineEnd := bytes.IndexByte(rest, '\n')
+		if lineEnd == -1 {
+			lineEnd = len(rest) - 1
+		}
+		if len(bytes.TrimSpace(rest[:lineEnd+1])) == 0 {
+			sw.edits = append(sw.edits, edit{start: sw.getLineStart(start), end: end + lineEnd + 1})
+			return
+		}
+	}
+	// 和前面的语句在同一行，同时删除前面的空白
+	for start > 0 && (sw.source[start-1] == ' ' || sw.source[start-1] == '\t') {
+		start--
+	}
+	sw.edits = append(sw.edits, edit{start: start, end: end})
+}
+
+// insertLines 在 offset 所在行的前面插入若干行，offset 前面还有其他内容时在 offset 处换行插入
+func (sw *sourceWriter) insertLines(offset int, indent string, lines []string) {
+	var buf bytes.Buffer
+	if !sw.onlySpaceBefore(offset) {
+		// 替换掉 offset 前面的空白
+		start := offset
+		for sw.source[start-1] == ' ' || sw.source[start-1] == '\t' {
+			start--
+		}
+		buf.WriteString(sw.newline)
+		for _, line := range lines {
+			buf.WriteString(indent + line + sw.newline)
+		}
+		buf.WriteString(sw.getIndent(offset))
+		sw.edits = append(sw.edits, edit{start: start, end: offset, text: buf.String()})
+		return
+	}
+	for _, line := range lines {
+		buf.WriteString(indent + line + sw.newline)
+	}
+	lineStart := sw.getLineStart(offset)
+	sw.edits = append(sw.edits, edit{start: lineStart, end: lineStart, text: buf.String()})
+}
+
+// addProps 处理一组属性，新加的属性插入到 offset 之前
+func (sw *sourceWriter) addProps(props []*Property, format string, offset int, indent string) {
+	var newLines []string
+	for _, prop := range props {
+		private := strings.HasPrefix(prop.name, "_")
+		if !prop.span.hasSource() {
+			if !private {
+				newLines = append(newLines, prop.name+format+propValueToString(prop.value))
+			}
+			continue
+		}
+		if private {
+			sw.removeStatement(prop.span)
+		} else if prop.modified {
+			sw.edits = append(sw.edits, edit{
+				start: prop.valueSpan.start,
+				end:   prop.valueSpan.end,
+				text:  propValueToString(prop.value),
+			})
+		}
+	}
+	if len(newLines) > 0 {
+		sw.insertLines(offset, indent, newLines)
+	}
+}
+
+// addComponents 处理一组组件，新加的组件插入到 offset 之前
+func (sw *sourceWriter) addComponents(comps []*Component, offset int, indent string) {
+	var newLines []string
+	for _, comp := range comps {
+		if comp.span.hasSource() {
+			sw.addComponent(comp)
+			continue
+		}
+		var buf bytes.Buffer
+		comp.writeTo(&buf, 0)
+		newLines = append(newLines, strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")...)
+	}
+	if len(newLines) > 0 {
+		sw.insertLines(offset, indent, newLines)
+	}
+}
+
+func (sw *sourceWriter) addComponent(c *Component) {
+	// 最后的 } 的位置
+	closeOffset := c.span.end - 1
+	indent := sw.getIndent(c.span.start) + "    "
+	for _, node := range c.Nodes {
+		start, _ := node.Span()
+		if sw.onlySpaceBefore(start) {
+			indent = sw.getIndent(start)
+			break
+		}
+	}
+	sw.addProps(c.Props, " = ", closeOffset, indent)
+	sw.addComponents(c.Children, closeOffset, indent)
+}
+
+func (sw *sourceWriter) writeTo(w io.Writer) {
+	sort.SliceStable(sw.edits, func(i, j int) bool {
+		return sw.edits[i].start < sw.edits[j].start
+	})
+	pos := 0
+	for _, e := range sw.edits {
+		w.Write(sw.source[pos:e.start])
+		io.WriteString(w, e.text)
+		pos = e.end
+	}
+	w.Write(sw.source[pos:])
+}
+
+// writeSource 在源文件的基础上输出主题。新加的全局属性放在最后一个全局属性之后，
+// 新加的组件放在文件末尾。
+func (t *Theme) writeSource(w io.Writer) {
+	sw := &sourceWriter{source: t.source, newline: "\n"}
+	if bytes.Contains(t.source, []byte("\r\n")) {
+		sw.newline = "\r\n"
+	}
+
+	propsEnd := 0
+	for _, prop := range t.Props {
+		if prop.span.hasSource() {
+			propsEnd = prop.span.end
+		}
+	}
+	if propsEnd > 0 {
+		// 插入到下一行
+		if idx := bytes.IndexByte(t.source[propsEnd:], '\n'); idx >= 0 {
+			propsEnd += idx + 1
+		} else {
+			propsEnd = len(t.source)
+		}
+	} else if bytes.HasPrefix(t.source, bom) {
+		propsEnd = len(bom)
+	}
+	sw.addProps(t.Props, ": ", propsEnd, "")
+
+	sw.addComponents(t.Components, len(t.source), "")
+	sw.writeTo(w)
+}
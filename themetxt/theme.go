@@ -3,6 +3,8 @@ package themetxt
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
+	"log"
 	"strconv"
 	"strings"
 )
@@ -21,33 +23,62 @@ const (
 type Property struct {
 	name  string
 	value interface{}
+	span
+	valueSpan span
+	// 属性值在源文件中的原文
+	raw string
+	// 通过 SetProp 修改过
+	modified bool
 }
 
+// Length 是组件的位置或大小，值为 abs + rel% * 父组件的宽或高，
+// 参考 grub 的 parse_proportional_spec
 type Length interface {
 	GetConvertFunc() func(val float64) float64
 }
 
+// GetLengthParts 返回长度的绝对部分和相对部分，rel 的单位是 %，都可以是负数
+func GetLengthParts(l Length) (abs, rel int) {
+	switch v := l.(type) {
+	case AbsNum:
+		return int(v), 0
+	case RelNum:
+		return 0, int(v)
+	case CombinedNum:
+		if v.Op == CombinedNumSub {
+			return -v.Abs, v.Rel
+		}
+		return v.Abs, v.Rel
+	}
+	return 0, 0
+}
+
+func getConvertFunc(l Length) func(val float64) float64 {
+	abs, rel := GetLengthParts(l)
+	return func(val float64) float64 {
+		return float64(rel)/100.0*val + float64(abs)
+	}
+}
+
 // 50
 type AbsNum int
 
 func (v AbsNum) GetConvertFunc() func(val float64) float64 {
-	return func(val float64) float64 {
-		return float64(v)
-	}
+	return getConvertFunc(v)
 }
 
 // 50%
 type RelNum int
 
 func (v RelNum) GetConvertFunc() func(val float64) float64 {
-	return func(val float64) float64 {
-		return float64(v) / 100.0 * val
-	}
+	return getConvertFunc(v)
 }
 
 // 50%-10
 // rel: 50
 // abs: 10
+// op: CombinedNumSub
+// 有多个项的长度，比如 10+50%-5，也合并为一个 CombinedNum
 type CombinedNum struct {
 	Rel int
 	Abs int
@@ -62,40 +93,52 @@ const (
 )
 
 func (v CombinedNum) GetConvertFunc() func(val float64) float64 {
-	return func(val float64) float64 {
-		rel := float64(v.Rel) / 100.0 * val
-		return rel - float64(v.Abs)
-	}
+	return getConvertFunc(v)
 }
 
 type Component struct {
 	Type     string
 	Props    []*Property
 	Children []*Component
+	// Nodes 按源文件中的顺序保存属性、子组件和注释
+	Nodes []Node
+	span
 }
 
 func (c *Component) GetProp(name string) (interface{}, bool) {
 	return getProp(c.Props, name)
 }
 
+// GetPropString 读取属性，属性不存在时返回属性描述中的默认值，第二个返回值为 false。
+// GetPropLength、GetPropBool 和 GetPropNumber 也是这样。
 func (c *Component) GetPropString(name string) (string, bool) {
-	return getPropString(c.Props, name)
+	return getPropString(c.Props, name, getDefault(c.Type, name))
 }
 
 func (c *Component) GetPropLength(name string) (Length, bool) {
-	return getPropLength(c.Props, name)
+	return getPropLength(c.Props, name, getDefault(c.Type, name))
 }
 
+// GetPropBool 读取 bool 属性，和 grub 一样按照属性的默认值解释属性值
 func (c *Component) GetPropBool(name string) (bool, bool) {
-	return getPropBool(c.Props, name)
+	return getPropBool(c.Props, name, getDefault(c.Type, name))
+}
+
+func (c *Component) GetPropNumber(name string) (int, bool) {
+	return getPropNumber(c.Props, name, getDefault(c.Type, name))
+}
+
+// FindProp 返回属性，有多个同名属性时返回最后一个，没有时返回 nil
+func (c *Component) FindProp(name string) *Property {
+	return findProp(c.Props, name)
 }
 
 func (c *Component) SetProp(name string, value interface{}) {
-	for _, prop := range c.Props {
-		if prop.name == name {
-			prop.value = value
-			return
-		}
+	if prop := findProp(c.Props, name); prop != nil {
+		prop.value = value
+		prop.raw = ""
+		prop.modified = true
+		return
 	}
 
 	newProp := &Property{name: name, value: value}
@@ -125,8 +168,7 @@ func (c *Component) writeTo(w io.Writer, indent int) {
 		if strings.HasPrefix(prop.name, "_") {
 			continue
 		}
-		fmt.Fprintf(w, "%s    %s = %s\n", indentStr, prop.name,
-			propValueToString(prop.value))
+		fmt.Fprintf(w, "%s    %s = %s\n", indentStr, prop.name, prop.Raw())
 	}
 
 	for _, child := range c.Children {
@@ -143,65 +185,116 @@ func (c *Component) WriteTo(w io.Writer) {
 func propValueToString(value interface{}) string {
 	switch val := value.(type) {
 	case string:
-		return strconv.Quote(val)
+		return quoteString(val)
 	case AbsNum:
 		return strconv.Itoa(int(val))
 	case RelNum:
 		return strconv.Itoa(int(val)) + "%"
 	case CombinedNum:
-		var format string
-		switch val.Op {
-		case CombinedNumAdd:
-			format = "%d%%+%d"
-		case CombinedNumSub:
-			format = "%d%%-%d"
-		}
-		return fmt.Sprintf(format, val.Rel, val.Abs)
+		abs, rel := GetLengthParts(val)
+		return fmt.Sprintf("%d%%%+d", rel, abs)
 	default:
 		return fmt.Sprintf("%v", val)
 	}
 }
 
-func getPropString(props []*Property, name string) (string, bool) {
-	v, ok := getProp(props, name)
-	if ok {
-		return v.(string), true
+// getPropString 读取属性，defaultValue 是属性描述中的默认值，可以是 nil
+func getPropString(props []*Property, name string, defaultValue interface{}) (string, bool) {
+	prop := findProp(props, name)
+	if prop == nil {
+		switch val := defaultValue.(type) {
+		case nil:
+			return "", false
+		case string:
+			return val, false
+		default:
+			return propValueToString(val), false
+		}
+	}
+	return prop.GrubString(), true
+}
+
+// getPropBool 读取 bool 属性，grub 按照属性的默认值解释属性值
+func getPropBool(props []*Property, name string, defaultValue interface{}) (bool, bool) {
+	def, _ := defaultValue.(bool)
+	prop := findProp(props, name)
+	if prop == nil {
+		return def, false
 	}
-	return "", false
+	return GetBool(prop.GrubString(), def), true
 }
 
-func getPropBool(props []*Property, name string) (bool, bool) {
-	v, ok := getProp(props, name)
-	if ok {
-		return v.(bool), true
+// getPropNumber 读取 grub 中用 grub_strtol 解析的属性，比如 item_height
+func getPropNumber(props []*Property, name string, defaultValue interface{}) (int, bool) {
+	prop := findProp(props, name)
+	if prop == nil {
+		def, _ := defaultValue.(AbsNum)
+		return int(def), false
+	}
+	val, complete := parseGrubInt(prop.GrubString())
+	if !complete {
+		log.Printf("WARN: property %s: %s is not a number, use %d\n", name, prop.Raw(), val)
 	}
-	return false, false
+	return val, true
 }
 
-func getPropLength(props []*Property, name string) (Length, bool) {
-	v, ok := getProp(props, name)
-	if ok {
-		return v.(Length), true
+func getPropLength(props []*Property, name string, defaultValue interface{}) (Length, bool) {
+	def, _ := defaultValue.(Length)
+	prop := findProp(props, name)
+	if prop == nil {
+		return def, false
+	}
+	l, err := ParseLength(prop.GrubString())
+	if err != nil {
+		log.Printf("WARN: property %s: %v\n", name, err)
+		return def, false
 	}
-	return nil, false
+	return l, true
 }
 
 func getProp(props []*Property, name string) (interface{}, bool) {
-	for _, prop := range props {
-		if prop.name == name {
-			return prop.value, true
-		}
+	prop := findProp(props, name)
+	if prop == nil {
+		return nil, false
 	}
-	return nil, false
+	return prop.value, true
 }
 
 type Theme struct {
 	Props      []*Property
 	Components []*Component
+	// Nodes 按源文件中的顺序保存全局属性、组件和注释
+	Nodes []Node
+	// 解析得到的主题保留源文件，用来输出时保留注释和格式
+	source   []byte
+	filename string
+}
+
+func (t *Theme) GetProp(name string) (interface{}, bool) {
+	return getProp(t.Props, name)
 }
 
+// GetPropString 读取全局属性，属性不存在时返回属性描述中的默认值，第二个返回值为 false
 func (t *Theme) GetPropString(name string) (string, bool) {
-	return getPropString(t.Props, name)
+	return getPropString(t.Props, name, getDefault("", name))
+}
+
+func (t *Theme) GetPropLength(name string) (Length, bool) {
+	return getPropLength(t.Props, name, getDefault("", name))
+}
+
+func (t *Theme) GetPropNumber(name string) (int, bool) {
+	return getPropNumber(t.Props, name, getDefault("", name))
+}
+
+// FindProp 返回全局属性，有多个同名属性时返回最后一个，没有时返回 nil
+func (t *Theme) FindProp(name string) *Property {
+	return findProp(t.Props, name)
+}
+
+// Filename 返回解析的文件名
+func (t *Theme) Filename() string {
+	return t.filename
 }
 
 func (t *Theme) Dump() {
@@ -213,19 +306,38 @@ func (t *Theme) Dump() {
 	}
 }
 
+// WriteTo 输出主题，解析得到的主题只改动修改过的属性，
+// 以 _ 开头的属性不会输出。
 func (t *Theme) WriteTo(w io.Writer) {
+	if t.source != nil {
+		t.writeSource(w)
+		return
+	}
 	for _, prop := range t.Props {
-		fmt.Fprintf(w, "%s : %s\n", prop.name, propValueToString(prop.value))
+		fmt.Fprintf(w, "%s : %s\n", prop.name, prop.Raw())
 	}
 	for _, comp := range t.Components {
 		comp.WriteTo(w)
 	}
 }
 
+// ParseThemeFile 解析 theme.txt，语法错误的类型是 *ParseError
 func ParseThemeFile(filename string) (*Theme, error) {
-	v, err := ParseFile(filename)
+	data, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
-	return v.(*Theme), nil
+	return ParseTheme(filename, data)
+}
+
+// ParseTheme 解析 theme.txt 的内容，filename 用于错误信息
+func ParseTheme(filename string, data []byte) (*Theme, error) {
+	v, err := Parse(filename, data)
+	if err != nil {
+		return nil, newParseError(filename, data, err)
+	}
+	theme := v.(*Theme)
+	theme.source = data
+	theme.filename = filename
+	return theme, nil
 }
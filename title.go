@@ -0,0 +1,53 @@
+package main
+
+import (
+	"image/color"
+
+	tt "github.com/electricface/grub-theme-viewer/themetxt"
+
+	"github.com/fogleman/gg"
+)
+
+// Title 对应全局属性 title-text, title-font 和 title-color
+type Title struct {
+	text  string
+	font  string
+	color string
+}
+
+func newTitle(theme *tt.Theme) *Title {
+	t := &Title{}
+	t.text, _ = theme.GetPropString("title-text")
+
+	t.font, _ = theme.GetPropString("title-font")
+
+	t.color, _ = theme.GetPropString("title-color")
+	return t
+}
+
+func (t *Title) getColor() color.Color {
+	return parseColor(t.color)
+}
+
+// titleToNode 参考 grub 的 draw_title，标题水平居中，基线在 40 + ascent 处。
+func titleToNode(theme *tt.Theme, parent *Node) *Node {
+	title := newTitle(theme)
+	if title.text == "" {
+		return nil
+	}
+
+	fontFace := getFont(title.font)
+	textWidth := fontFace.MeasureString(title.text)
+
+	// left = (screenWidth - textWidth) / 2
+	node := &Node{
+		leftExpr:   div(sub(nodeWidth{parent}, AbsNum(textWidth)), AbsNum(2)),
+		topExpr:    AbsNum(40),
+		widthExpr:  AbsNum(textWidth),
+		heightExpr: AbsNum(fontFace.Height()),
+	}
+	node.draw = func(n *Node, ctx *gg.Context, ec *EvalContext) {
+		n.drawText(ctx, ec, title.text, title.getColor(), fontFace)
+	}
+	return node
+}
@@ -17,11 +17,10 @@ import (
 	"strconv"
 	"strings"
 
-	"pkg.deepin.io/lib/locale"
-
 	"github.com/nfnt/resize"
 
 	"github.com/electricface/grub-theme-viewer/font"
+	"github.com/electricface/grub-theme-viewer/gettext"
 
 	tt "github.com/electricface/grub-theme-viewer/themetxt"
 )
@@ -169,10 +168,8 @@ func main() {
 		if comp.Type == tt.ComponentTypeBootMenu {
 			adjustBootMenu(comp, vars)
 
-			iconWidth0, _ := comp.GetProp("icon_width")
-			iconWidth := iconWidth0.(int)
-			iconHeight0, _ := comp.GetProp("icon_height")
-			iconHeight := iconHeight0.(int)
+			iconWidth, _ := comp.GetPropNumber("icon_width")
+			iconHeight, _ := comp.GetPropNumber("icon_height")
 			adjustResourcesOsLogos(iconWidth, iconHeight)
 
 		} else if comp.Type == tt.ComponentTypeLabel {
@@ -301,7 +298,7 @@ func adjustLabel(comp *tt.Component, vars map[string]float64) {
 		adjustProp(comp, propName, vars)
 	}
 
-	localeVars := locale.GetLocaleVariants(optLang)
+	localeVars := gettext.GetLocaleVariants(optLang)
 	var text string
 	var textFound bool
 	for _, localeVar := range localeVars {